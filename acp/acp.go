@@ -0,0 +1,154 @@
+// Package acp pays into anyone-can-pay cells, which accept CKB and token payments without the owner signature.
+package acp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/collector"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+	"github.com/shaojunda/ckb-rich-sdk-go/udt"
+)
+
+const pubKeyHashLength = 20
+
+// ErrCellNotFound is returned when the recipient has no anyone-can-pay cell of the token.
+var ErrCellNotFound = errors.New("anyone-can-pay cell not found")
+
+// Config is a deployment of the anyone-can-pay lock.
+type Config struct {
+	CodeHash types.Hash
+	HashType types.ScriptHashType
+	CellDep  *types.CellDep
+}
+
+var (
+	MainnetACP = configOf(systemscript.Mainnet.MustGet(systemscript.ACP))
+	TestnetACP = configOf(systemscript.Testnet.MustGet(systemscript.ACP))
+)
+
+// NewConfig returns the anyone-can-pay deployment of the registry, such as a devnet registry it is registered in.
+func NewConfig(registry *systemscript.Registry) (*Config, error) {
+	script, err := registry.Get(systemscript.ACP)
+	if err != nil {
+		return nil, err
+	}
+	return configOf(script), nil
+}
+
+func configOf(script *systemscript.Script) *Config {
+	return &Config{
+		CodeHash: script.CodeHash,
+		HashType: script.HashType,
+		CellDep:  script.CellDep,
+	}
+}
+
+// ACPConfig returns the anyone-can-pay deployment of the network.
+func ACPConfig(mode address.Mode) (*Config, error) {
+	registry, err := systemscript.ForNetwork(mode)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfig(registry)
+}
+
+// Args are the args of an anyone-can-pay lock: the owner public key hash and the minimum payments it accepts.
+type Args struct {
+	PubKeyHash []byte
+	// MinimumCKB is the exponent of ten of the minimum CKB payment in shannons, nil accepts any payment.
+	MinimumCKB *uint8
+	// MinimumUDT is the exponent of ten of the minimum token payment, nil accepts any payment.
+	MinimumUDT *uint8
+}
+
+func ParseArgs(args []byte) (*Args, error) {
+	if len(args) < pubKeyHashLength || len(args) > pubKeyHashLength+2 {
+		return nil, fmt.Errorf("anyone-can-pay args are %d bytes", len(args))
+	}
+	a := &Args{
+		PubKeyHash: args[:pubKeyHashLength],
+	}
+	if len(args) > pubKeyHashLength {
+		minimum := args[pubKeyHashLength]
+		a.MinimumCKB = &minimum
+	}
+	if len(args) > pubKeyHashLength+1 {
+		minimum := args[pubKeyHashLength+1]
+		a.MinimumUDT = &minimum
+	}
+	return a, nil
+}
+
+// Serialize encodes the args, a token minimum without a CKB minimum is preceded by a zero CKB exponent.
+func (a *Args) Serialize() []byte {
+	args := append([]byte{}, a.PubKeyHash...)
+	if a.MinimumCKB == nil && a.MinimumUDT == nil {
+		return args
+	}
+	var minimumCKB uint8
+	if a.MinimumCKB != nil {
+		minimumCKB = *a.MinimumCKB
+	}
+	args = append(args, minimumCKB)
+	if a.MinimumUDT != nil {
+		args = append(args, *a.MinimumUDT)
+	}
+	return args
+}
+
+// Script returns the anyone-can-pay lock of the args.
+func (c *Config) Script(args *Args) (*types.Script, error) {
+	if len(args.PubKeyHash) != pubKeyHashLength {
+		return nil, fmt.Errorf("public key hash is %d bytes, want %d", len(args.PubKeyHash), pubKeyHashLength)
+	}
+	return &types.Script{
+		CodeHash: c.CodeHash,
+		HashType: c.HashType,
+		Args:     args.Serialize(),
+	}, nil
+}
+
+// Address returns the full address of the anyone-can-pay lock of the args.
+func (c *Config) Address(mode address.Mode, args *Args) (string, error) {
+	script, err := c.Script(args)
+	if err != nil {
+		return "", err
+	}
+	return address.Generate(mode, script)
+}
+
+// Matches reports whether the lock is an anyone-can-pay lock of the deployment.
+func (c *Config) Matches(lock *types.Script) bool {
+	return lock != nil && lock.CodeHash == c.CodeHash && lock.HashType == c.HashType
+}
+
+// FindCell returns the anyone-can-pay cell of the lock holding the token, or holding only CKB when token is nil.
+func FindCell(ctx context.Context, source collector.CellSource, lock *types.Script, token *types.Script) (*indexer.LiveCell, error) {
+	c := collector.NewCellCollector(source, &indexer.SearchKey{
+		Script:     lock,
+		ScriptType: indexer.ScriptTypeLock,
+		ArgsLen:    uint(len(lock.Args)),
+	}, nil)
+	c.AllowTypeScript = true
+	c.AllowData = true
+	c.MaxCells = 1
+	c.Skip = func(cell *indexer.LiveCell) bool {
+		if token == nil {
+			return cell.Output.Type != nil || len(cell.OutputData) > 0
+		}
+		return !sameScript(token, cell.Output.Type) || len(cell.OutputData) < udt.AmountLength
+	}
+	cells, err := c.Candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(cells) == 0 {
+		return nil, ErrCellNotFound
+	}
+	return cells[0], nil
+}
@@ -0,0 +1,158 @@
+package acp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/builder"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+const senderAddress = "ckb1qyqwmndf2yl6qvxwgvyw9yj95gkqytgygwasshh9m8"
+
+var ownerHash = common.FromHex("0xc8328aabcd9b9e8e64fbc566c4385c3bdeb219d7")
+
+// chainClient serves the live cells of the search key lock and the mainnet genesis system cells, leaving the other
+// methods unimplemented.
+type chainClient struct {
+	rpc.Client
+	cells []*indexer.LiveCell
+}
+
+func (c *chainClient) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	var cells []*indexer.LiveCell
+	for _, cell := range c.cells {
+		if cell.Output.Lock.CodeHash == searchKey.Script.CodeHash && bytes.Equal(cell.Output.Lock.Args, searchKey.Script.Args) {
+			cells = append(cells, cell)
+		}
+	}
+	return &indexer.LiveCells{Objects: cells}, nil
+}
+
+func (c *chainClient) GetBlockByNumber(ctx context.Context, number uint64) (*types.Block, error) {
+	typeID := func(args string) *types.Script {
+		return &types.Script{
+			CodeHash: types.HexToHash("0x00000000000000000000000000000000000000000000000000545950455f4944"),
+			HashType: types.HashTypeType,
+			Args:     common.FromHex(args),
+		}
+	}
+	return &types.Block{Transactions: []*types.Transaction{
+		{
+			Hash: types.HexToHash("0xe2fb199810d49a4d8beec56718ba2593b665db9d52299a0f9e6e75416d73ff5c"),
+			Outputs: []*types.CellOutput{
+				{},
+				{Type: typeID("0x8536c9d5d908bd89fc70099e4284870708b6632356aad98734fcf43f6f71c304")},
+				{Type: typeID("0xb2a8500929d6a1294bf9bf1bf565f549fa4a5f1316a3306ad3d4783e64bcf626")},
+				{},
+				{Type: typeID("0xd813c1b15bd79c8321ad7f5819e5d9f659a1042b72e64659a2c092be68ea9758")},
+			},
+		},
+		{
+			Hash:    types.HexToHash("0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c"),
+			Outputs: []*types.CellOutput{{}, {}},
+		},
+	}}, nil
+}
+
+func liveCell(index uint, lock *types.Script, capacity uint64) *indexer.LiveCell {
+	return &indexer.LiveCell{
+		OutPoint: &types.OutPoint{
+			TxHash: types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541"),
+			Index:  index,
+		},
+		Output:     &types.CellOutput{Capacity: capacity, Lock: lock},
+		OutputData: []byte{},
+	}
+}
+
+func TestArgs(t *testing.T) {
+	minimum := uint8(2)
+	tests := []struct {
+		args *Args
+		data string
+	}{
+		{&Args{PubKeyHash: ownerHash}, "c8328aabcd9b9e8e64fbc566c4385c3bdeb219d7"},
+		{&Args{PubKeyHash: ownerHash, MinimumCKB: &minimum}, "c8328aabcd9b9e8e64fbc566c4385c3bdeb219d702"},
+		// a token minimum is preceded by a zero CKB exponent.
+		{&Args{PubKeyHash: ownerHash, MinimumUDT: &minimum}, "c8328aabcd9b9e8e64fbc566c4385c3bdeb219d70002"},
+	}
+	for _, tt := range tests {
+		data := tt.args.Serialize()
+		if common.Bytes2Hex(data) != tt.data {
+			t.Errorf("serialized args are %x, want %s", data, tt.data)
+		}
+		parsed, err := ParseArgs(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(parsed.Serialize(), data) {
+			t.Errorf("parsed args serialize to %x, want %x", parsed.Serialize(), data)
+		}
+	}
+	if _, err := ParseArgs(make([]byte, 23)); err == nil {
+		t.Error("parsed args of 23 bytes")
+	}
+}
+
+func TestPayCKB(t *testing.T) {
+	lock, err := MainnetACP.Script(&Args{PubKeyHash: ownerHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := address.Generate(address.Mainnet, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := address.Parse(senderAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := liveCell(0, lock, 61*100000000)
+	client := &chainClient{cells: []*indexer.LiveCell{recipient, liveCell(1, sender.Script, 200*100000000)}}
+
+	tx, groups, err := NewBuilder(client, MainnetACP).PayCKB(context.Background(), senderAddress, to, 100*100000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Inputs) != 2 || tx.Outputs[0].Capacity != 161*100000000 || !MainnetACP.Matches(tx.Outputs[0].Lock) {
+		t.Fatalf("payment spends %d inputs into %d shannons", len(tx.Inputs), tx.Outputs[0].Capacity)
+	}
+	// the recipient cell is unlocked without signature, its witness stays empty and the fee is computed so.
+	if len(groups) != 1 || groups[0].InputIndices[0] != 1 || len(tx.Witnesses[0]) != 0 {
+		t.Errorf("got %d groups and recipient witness %x", len(groups), tx.Witnesses[0])
+	}
+	var outputs uint64
+	for _, output := range tx.Outputs {
+		outputs += output.Capacity
+	}
+	minimum, err := transaction.MinimumFee(tx, builder.DefaultFeeRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee := 261*100000000 - outputs; fee != minimum {
+		t.Errorf("fee is %d, want the minimum fee %d", fee, minimum)
+	}
+
+	exponent := uint8(9)
+	lock, err = MainnetACP.Script(&Args{PubKeyHash: ownerHash, MinimumCKB: &exponent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err = address.Generate(address.Mainnet, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := NewBuilder(client, MainnetACP).PayCKB(context.Background(), senderAddress, to, 100000000); err == nil {
+		t.Error("paid less than the minimum of the recipient")
+	}
+	if _, _, err := NewBuilder(client, MainnetACP).PayCKB(context.Background(), senderAddress, senderAddress, 100000000); err == nil {
+		t.Error("paid into a secp256k1-blake160 address")
+	}
+}
@@ -0,0 +1,161 @@
+package acp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/builder"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+	"github.com/shaojunda/ckb-rich-sdk-go/udt"
+)
+
+// Builder builds unsigned payments from secp256k1-blake160 addresses into anyone-can-pay cells. The recipient cell
+// is spent and re-created with the payment added, its input needs no signature.
+type Builder struct {
+	client rpc.Client
+	config *Config
+	// FeeRate is the fee rate in shannons per KB.
+	FeeRate uint64
+}
+
+func NewBuilder(client rpc.Client, config *Config) *Builder {
+	return &Builder{
+		client:  client,
+		config:  config,
+		FeeRate: builder.DefaultFeeRate,
+	}
+}
+
+// PayCKB pays capacity shannons from the sender address into the CKB anyone-can-pay cell of the recipient address.
+func (b *Builder) PayCKB(ctx context.Context, from string, to string, capacity uint64) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	lock, args, err := b.recipient(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if args.MinimumCKB != nil && new(big.Int).SetUint64(capacity).Cmp(pow10(*args.MinimumCKB)) < 0 {
+		return nil, nil, fmt.Errorf("payment of %d shannons is less than the minimum 10^%d", capacity, *args.MinimumCKB)
+	}
+	cell, err := FindCell(ctx, b.client, lock, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := b.transferBuilder()
+	err = t.AddSender(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.AddInput(cell)
+	t.AddOutput(&types.CellOutput{
+		Capacity: cell.Output.Capacity + capacity,
+		Lock:     cell.Output.Lock,
+	}, cell.OutputData)
+	return b.build(ctx, t, lock)
+}
+
+// PayUDT pays amount of the token from the sender address into the anyone-can-pay cell of the token of the recipient
+// address. The token left in the spent sender token cells returns to the sender in a new token cell.
+func (b *Builder) PayUDT(ctx context.Context, from string, to string, token *types.Script, tokenConfig *udt.Config, amount *big.Int) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	lock, args, err := b.recipient(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if args.MinimumUDT != nil && amount.Cmp(pow10(*args.MinimumUDT)) < 0 {
+		return nil, nil, fmt.Errorf("payment of %s is less than the minimum 10^%d", amount.String(), *args.MinimumUDT)
+	}
+	cell, err := FindCell(ctx, b.client, lock, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	balance, err := udt.ParseAmount(cell.OutputData)
+	if err != nil {
+		return nil, nil, err
+	}
+	parsedFrom, err := address.Parse(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokenCells, err := udt.Cells(ctx, b.client, parsedFrom.Script, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs, change, err := udt.SelectAmount(tokenCells, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := b.transferBuilder()
+	t.AddCellDep(tokenConfig.CellDep)
+	err = t.AddSender(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.AddInput(cell)
+	for _, input := range inputs {
+		t.AddInput(input)
+	}
+	data, err := udt.EncodeAmount(new(big.Int).Add(balance, amount))
+	if err != nil {
+		return nil, nil, err
+	}
+	// the amount is followed by the optional data the recipient cell holds.
+	t.AddOutput(&types.CellOutput{
+		Capacity: cell.Output.Capacity,
+		Lock:     cell.Output.Lock,
+		Type:     cell.Output.Type,
+	}, append(data, cell.OutputData[udt.AmountLength:]...))
+	if change.Sign() > 0 {
+		data, err := udt.EncodeAmount(change)
+		if err != nil {
+			return nil, nil, err
+		}
+		t.AddOutput(&types.CellOutput{
+			Capacity: udt.OccupiedCapacity(parsedFrom.Script, token),
+			Lock:     parsedFrom.Script,
+			Type:     token,
+		}, data)
+	}
+	return b.build(ctx, t, lock)
+}
+
+func (b *Builder) recipient(to string) (*types.Script, *Args, error) {
+	parsed, err := address.Parse(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !b.config.Matches(parsed.Script) {
+		return nil, nil, fmt.Errorf("recipient %s is not an anyone-can-pay address", to)
+	}
+	args, err := ParseArgs(parsed.Script.Args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parsed.Script, args, nil
+}
+
+func (b *Builder) transferBuilder() *builder.TransferBuilder {
+	t := builder.NewTransferBuilder(b.client)
+	t.FeeRate = b.FeeRate
+	t.AddCellDep(b.config.CellDep)
+	return t
+}
+
+// build builds the transaction and leaves the witness of the recipient cell empty, the anyone-can-pay lock only
+// checks the payment of a group without signature. The fee is computed without its witness.
+func (b *Builder) build(ctx context.Context, t *builder.TransferBuilder, lock *types.Script) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	t.AddUnsignedLock(lock)
+	return t.Build(ctx)
+}
+
+func sameScript(a *types.Script, b *types.Script) bool {
+	return b != nil && a.CodeHash == b.CodeHash && a.HashType == b.HashType && bytes.Equal(a.Args, b.Args)
+}
+
+func pow10(exponent uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
@@ -0,0 +1,368 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	ckbtransaction "github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/collector"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/signer"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+const (
+	// DefaultFeeRate is the fee rate in shannons per KB used when none is given.
+	DefaultFeeRate = 1000
+
+	maxIterations = 10
+)
+
+// TransferBuilder builds unsigned transfers of CKB from secp256k1-blake160 sender addresses, or other sender locks,
+// to recipient addresses.
+type TransferBuilder struct {
+	client rpc.Client
+	// FeeRate is the fee rate in shannons per KB.
+	FeeRate uint64
+	// Strategy selects the sender cells, defaults to collector.LargestFirst.
+	Strategy collector.Strategy
+	// ChangeLock receives the change, defaults to the lock of the first sender.
+	ChangeLock *types.Script
+	// RequireSenderInput spends at least one sender cell even when the fixed inputs cover the outputs, for scripts
+	// which check for an input of the sender lock.
+	RequireSenderInput bool
+	// Registry holds the secp256k1-blake160 dep group of the chain. When nil, it is read from the genesis block on
+	// the first Build.
+	Registry *systemscript.Registry
+
+	senders       []*types.Script
+	lockSizes     []int
+	senderSinces  []uint64
+	unsignedLocks []*types.Script
+	inputs        []*indexer.LiveCell
+	inputTypes    [][]byte
+	inputSinces   []uint64
+	outputs       []*types.CellOutput
+	outputsData   [][]byte
+	cellDeps      []*types.CellDep
+	headerDeps    []types.Hash
+}
+
+func NewTransferBuilder(client rpc.Client) *TransferBuilder {
+	return &TransferBuilder{
+		client:   client,
+		FeeRate:  DefaultFeeRate,
+		Strategy: collector.LargestFirst{},
+	}
+}
+
+// AddSender adds a secp256k1-blake160 address whose cells pay for the transfer.
+func (b *TransferBuilder) AddSender(addr string) error {
+	parsed, err := address.Parse(addr)
+	if err != nil {
+		return err
+	}
+	if parsed.Script.CodeHash != types.HexToHash(ckbtransaction.SECP256K1_BLAKE160_SIGHASH_ALL_TYPE_HASH) || parsed.Script.HashType != types.HashTypeType {
+		return fmt.Errorf("sender %s is not a secp256k1-blake160 address", addr)
+	}
+	b.AddSenderScript(parsed.Script, signer.SignatureLength, 0)
+	return nil
+}
+
+// AddSenderScript adds a sender lock of any script whose cells pay for the transfer, such as an Omnilock. The first
+// witness of its group holds a zero-filled lock of witnessLockSize bytes for the fee, and its cells are spent no
+// earlier than since. The cell dep of the lock is added with AddCellDep.
+func (b *TransferBuilder) AddSenderScript(lock *types.Script, witnessLockSize int, since uint64) {
+	b.senders = append(b.senders, lock)
+	b.lockSizes = append(b.lockSizes, witnessLockSize)
+	b.senderSinces = append(b.senderSinces, since)
+}
+
+// AddUnsignedLock adds a lock whose inputs are unlocked without a signature, such as an anyone-can-pay cell receiving
+// a payment. The witnesses of its group stay empty, also when the fee is computed, and Build leaves its group out.
+func (b *TransferBuilder) AddUnsignedLock(lock *types.Script) {
+	b.unsignedLocks = append(b.unsignedLocks, lock)
+}
+
+// AddRecipient adds an output paying capacity shannons to the address.
+func (b *TransferBuilder) AddRecipient(addr string, capacity uint64) error {
+	parsed, err := address.Parse(addr)
+	if err != nil {
+		return err
+	}
+	b.outputs = append(b.outputs, &types.CellOutput{
+		Capacity: capacity,
+		Lock:     parsed.Script,
+	})
+	b.outputsData = append(b.outputsData, []byte{})
+	return nil
+}
+
+// AddInput adds a cell which is always spent, ahead of the collected sender cells. Its capacity counts towards the outputs and fee.
+func (b *TransferBuilder) AddInput(cell *indexer.LiveCell) {
+	b.addInput(cell, 0, nil)
+}
+
+// AddInputWithType adds a cell which is always spent, with the input type of its witness, such as the witness its type script reads.
+func (b *TransferBuilder) AddInputWithType(cell *indexer.LiveCell, inputType []byte) {
+	b.addInput(cell, 0, inputType)
+}
+
+// AddInputWithSince adds a cell which is always spent no earlier than since.
+func (b *TransferBuilder) AddInputWithSince(cell *indexer.LiveCell, since uint64) {
+	b.addInput(cell, since, nil)
+}
+
+func (b *TransferBuilder) addInput(cell *indexer.LiveCell, since uint64, inputType []byte) {
+	b.inputs = append(b.inputs, cell)
+	b.inputSinces = append(b.inputSinces, since)
+	b.inputTypes = append(b.inputTypes, inputType)
+}
+
+// AddOutput adds an output with its data, such as a cell with a type script.
+func (b *TransferBuilder) AddOutput(output *types.CellOutput, data []byte) {
+	b.outputs = append(b.outputs, output)
+	b.outputsData = append(b.outputsData, data)
+}
+
+// AddCellDep adds a cell dep required by the inputs or outputs, besides the secp256k1-blake160 dep group.
+func (b *TransferBuilder) AddCellDep(dep *types.CellDep) {
+	b.cellDeps = append(b.cellDeps, dep)
+}
+
+// AddHeaderDep adds a header dep, such as the deposit block of a DAO cell.
+func (b *TransferBuilder) AddHeaderDep(hash types.Hash) {
+	b.headerDeps = append(b.headerDeps, hash)
+}
+
+// Build collects the sender cells and returns the unsigned transaction with its change output and fee,
+// and the script groups to sign. A change below the occupied capacity of a cell is paid as fee when the senders have
+// no cell left to raise it. The first witness of every group holds a WitnessArgs with a zero-filled lock placeholder,
+// the groups of unsigned locks are left out.
+func (b *TransferBuilder) Build(ctx context.Context) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	if len(b.senders) == 0 {
+		return nil, nil, errors.New("no sender")
+	}
+	if len(b.outputs) == 0 {
+		return nil, nil, errors.New("no output")
+	}
+	// the outputs come first in the transaction, ahead of the change.
+	err := transaction.ValidateOutputs(&types.Transaction{
+		Outputs:     b.outputs,
+		OutputsData: b.outputsData,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cellDep, err := b.secpCellDep(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	candidates, err := b.candidates(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changeLock := b.ChangeLock
+	if changeLock == nil {
+		changeLock = b.senders[0]
+	}
+	minChange := transaction.OccupiedCapacity(&types.CellOutput{Lock: changeLock}, nil)
+	fixed := sum(b.inputs)
+	var total uint64
+	for _, output := range b.outputs {
+		total += output.Capacity
+	}
+
+	var fee uint64
+	for i := 0; i < maxIterations; i++ {
+		need := total + fee
+		cells, err := b.selectCells(candidates, fixed, need)
+		if err != nil {
+			return nil, nil, err
+		}
+		capacity := fixed + sum(cells)
+		change := capacity != need
+		if change {
+			withChange, err := b.selectCells(candidates, fixed, need+minChange)
+			switch {
+			case err == nil:
+				cells = withChange
+				capacity = fixed + sum(cells)
+			case errors.Is(err, collector.ErrInsufficientCapacity):
+				// no sender cell is left to raise the change to a cell, the remainder is paid as fee.
+				change = false
+			default:
+				return nil, nil, err
+			}
+		}
+
+		tx, groups, err := b.assemble(cellDep, cells)
+		if err != nil {
+			return nil, nil, err
+		}
+		if change {
+			tx.Outputs = append(tx.Outputs, &types.CellOutput{
+				Capacity: capacity - need,
+				Lock:     changeLock,
+			})
+			tx.OutputsData = append(tx.OutputsData, []byte{})
+		}
+
+		required, err := transaction.MinimumFee(tx, b.FeeRate)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !change && capacity >= total+required {
+			return tx, groups, nil
+		}
+		if change && capacity >= total+required+minChange {
+			tx.Outputs[len(tx.Outputs)-1].Capacity = capacity - total - required
+			return tx, groups, nil
+		}
+		fee = required
+	}
+	return nil, nil, errors.New("transfer fee does not converge")
+}
+
+// candidates returns the cells of the senders, without the fixed inputs already spent.
+func (b *TransferBuilder) candidates(ctx context.Context) ([]*indexer.LiveCell, error) {
+	fixed := make(map[string]bool)
+	for _, cell := range b.inputs {
+		fixed[outPointKey(cell.OutPoint)] = true
+	}
+	var candidates []*indexer.LiveCell
+	for _, sender := range b.senders {
+		c := collector.NewCellCollector(b.client, &indexer.SearchKey{
+			Script:     sender,
+			ScriptType: indexer.ScriptTypeLock,
+		}, b.Strategy)
+		cells, err := c.Candidates(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, cell := range cells {
+			if !fixed[outPointKey(cell.OutPoint)] {
+				candidates = append(candidates, cell)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// selectCells selects the sender cells covering what the fixed inputs leave of target.
+func (b *TransferBuilder) selectCells(candidates []*indexer.LiveCell, fixed uint64, target uint64) ([]*indexer.LiveCell, error) {
+	if fixed >= target {
+		if b.RequireSenderInput {
+			return b.Strategy.Select(candidates, 1)
+		}
+		return nil, nil
+	}
+	return b.Strategy.Select(candidates, target-fixed)
+}
+
+func (b *TransferBuilder) assemble(cellDep *types.CellDep, cells []*indexer.LiveCell) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	tx := &types.Transaction{
+		Version:     0,
+		CellDeps:    append([]*types.CellDep{cellDep}, b.cellDeps...),
+		HeaderDeps:  append([]types.Hash{}, b.headerDeps...),
+		Outputs:     append([]*types.CellOutput{}, b.outputs...),
+		OutputsData: append([][]byte{}, b.outputsData...),
+	}
+	cells = append(append([]*indexer.LiveCell{}, b.inputs...), cells...)
+	locks := make([]*types.Script, len(cells))
+	for i, cell := range cells {
+		var since uint64
+		if i < len(b.inputSinces) {
+			since = b.inputSinces[i]
+		} else if sender := b.sender(cell.Output.Lock); sender >= 0 {
+			since = b.senderSinces[sender]
+		}
+		tx.Inputs = append(tx.Inputs, &types.CellInput{
+			Since:          since,
+			PreviousOutput: cell.OutPoint,
+		})
+		tx.Witnesses = append(tx.Witnesses, []byte{})
+		locks[i] = cell.Output.Lock
+	}
+
+	all, err := transaction.GroupInputs(locks)
+	if err != nil {
+		return nil, nil, err
+	}
+	var groups []*transaction.ScriptGroup
+	for _, group := range all {
+		if indexOf(b.unsignedLocks, group.Script) < 0 {
+			groups = append(groups, group)
+		}
+	}
+	for i, inputType := range b.inputTypes {
+		if inputType != nil {
+			tx.Witnesses[i] = molecule.SerializeWitnessArgs(&types.WitnessArgs{
+				InputType: inputType,
+			})
+		}
+	}
+	lockSizes := make([]int, len(groups))
+	for i, group := range groups {
+		lockSizes[i] = signer.SignatureLength
+		if sender := b.sender(group.Script); sender >= 0 {
+			lockSizes[i] = b.lockSizes[sender]
+		}
+	}
+	err = transaction.FillPlaceholders(tx, groups, lockSizes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, groups, nil
+}
+
+// sender returns the index of the sender of the lock, or -1.
+func (b *TransferBuilder) sender(lock *types.Script) int {
+	return indexOf(b.senders, lock)
+}
+
+func indexOf(scripts []*types.Script, script *types.Script) int {
+	for i, s := range scripts {
+		if script.CodeHash == s.CodeHash && script.HashType == s.HashType && bytes.Equal(script.Args, s.Args) {
+			return i
+		}
+	}
+	return -1
+}
+
+// secpCellDep returns the secp256k1-blake160 dep group cell of the registry.
+func (b *TransferBuilder) secpCellDep(ctx context.Context) (*types.CellDep, error) {
+	if b.Registry == nil {
+		registry, err := systemscript.FromGenesis(ctx, b.client)
+		if err != nil {
+			return nil, err
+		}
+		b.Registry = registry
+	}
+	secp, err := b.Registry.Get(systemscript.Secp256k1Blake160)
+	if err != nil {
+		return nil, err
+	}
+	return secp.CellDep, nil
+}
+
+func outPointKey(outPoint *types.OutPoint) string {
+	return fmt.Sprintf("%s#%d", outPoint.TxHash.String(), outPoint.Index)
+}
+
+func sum(cells []*indexer.LiveCell) uint64 {
+	var total uint64
+	for _, cell := range cells {
+		total += cell.Output.Capacity
+	}
+	return total
+}
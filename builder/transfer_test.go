@@ -0,0 +1,236 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/collector"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+const (
+	senderAddress = "ckb1qyqwmndf2yl6qvxwgvyw9yj95gkqytgygwasshh9m8"
+	otherAddress  = "ckb1qyqvsv5240xeh85wvnau2eky8pwrhh4jr8ts6f6daz"
+)
+
+// cellsClient serves the live cells of the search key, leaving the other methods unimplemented.
+type cellsClient struct {
+	rpc.Client
+	cells []*indexer.LiveCell
+}
+
+func (c *cellsClient) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	var cells []*indexer.LiveCell
+	for _, cell := range c.cells {
+		if searchKey.Match(cell.Output) {
+			cells = append(cells, cell)
+		}
+	}
+	return &indexer.LiveCells{Objects: cells}, nil
+}
+
+func liveCell(t *testing.T, index uint, capacity uint64) *indexer.LiveCell {
+	return addressCell(t, senderAddress, index, capacity)
+}
+
+func addressCell(t *testing.T, addr string, index uint, capacity uint64) *indexer.LiveCell {
+	parsed, err := address.Parse(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &indexer.LiveCell{
+		OutPoint: &types.OutPoint{
+			TxHash: types.HexToHash("0x4bb9b6b2d37ac4fe8fd2be4ac3bf12e2a4d52baf9f0dcfce9da6bc4a7e6ab3b6"),
+			Index:  index,
+		},
+		Output: &types.CellOutput{
+			Capacity: capacity,
+			Lock:     parsed.Script,
+		},
+		OutputData: []byte{},
+	}
+}
+
+func TestBuildSkipsFixedInputs(t *testing.T) {
+	fixed := liveCell(t, 0, 300*100000000)
+	client := &cellsClient{cells: []*indexer.LiveCell{fixed, liveCell(t, 1, 100*100000000)}}
+
+	b := NewTransferBuilder(client)
+	b.Registry = systemscript.Mainnet
+	if err := b.AddSender(senderAddress); err != nil {
+		t.Fatal(err)
+	}
+	b.AddInput(fixed)
+	if err := b.AddRecipient(senderAddress, 330*100000000); err != nil {
+		t.Fatal(err)
+	}
+	tx, _, err := b.Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tx.Inputs) != 2 {
+		t.Fatalf("got %d inputs, want 2", len(tx.Inputs))
+	}
+	if tx.Inputs[0].PreviousOutput.Index != 0 || tx.Inputs[1].PreviousOutput.Index != 1 {
+		t.Errorf("got inputs #%d and #%d, want #0 and #1", tx.Inputs[0].PreviousOutput.Index, tx.Inputs[1].PreviousOutput.Index)
+	}
+	secp, err := systemscript.Mainnet.Get(systemscript.Secp256k1Blake160)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.CellDeps[0] != secp.CellDep {
+		t.Errorf("got cell dep %s#%d, want the registry secp256k1-blake160 dep group", tx.CellDeps[0].OutPoint.TxHash.String(), tx.CellDeps[0].OutPoint.Index)
+	}
+}
+
+func TestBuildUnsignedLock(t *testing.T) {
+	sender := liveCell(t, 1, 200*100000000)
+	client := &cellsClient{cells: []*indexer.LiveCell{sender}}
+	recipient := liveCell(t, 0, 100*100000000)
+	recipient.Output.Lock = &types.Script{
+		CodeHash: types.HexToHash("0xd369597ff47f29fbc0d47d2e3775370d1250b85140c670e4718af712983a2354"),
+		HashType: types.HashTypeType,
+		Args:     recipient.Output.Lock.Args,
+	}
+
+	b := NewTransferBuilder(client)
+	b.Registry = systemscript.Mainnet
+	if err := b.AddSender(senderAddress); err != nil {
+		t.Fatal(err)
+	}
+	b.AddUnsignedLock(recipient.Output.Lock)
+	b.AddInput(recipient)
+	b.AddOutput(&types.CellOutput{
+		Capacity: recipient.Output.Capacity + 50*100000000,
+		Lock:     recipient.Output.Lock,
+	}, []byte{})
+	tx, groups, err := b.Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 1 || groups[0].InputIndices[0] != 1 {
+		t.Fatalf("got %d groups, want the sender group only", len(groups))
+	}
+	if len(tx.Witnesses[0]) != 0 {
+		t.Errorf("unsigned input witness is %x, want empty", tx.Witnesses[0])
+	}
+	var capacity uint64
+	for _, output := range tx.Outputs {
+		capacity += output.Capacity
+	}
+	fee := recipient.Output.Capacity + sender.Output.Capacity - capacity
+	minimum, err := transaction.MinimumFee(tx, b.FeeRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee != minimum {
+		t.Errorf("fee is %d, want the minimum fee %d of the transaction", fee, minimum)
+	}
+}
+
+// transfer builds a transfer of amount to the other address from the senders, whose cells the client serves.
+func transfer(t *testing.T, cells []*indexer.LiveCell, amount uint64, senders ...string) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	b := NewTransferBuilder(&cellsClient{cells: cells})
+	b.Registry = systemscript.Mainnet
+	for _, sender := range senders {
+		if err := b.AddSender(sender); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.AddRecipient(otherAddress, amount); err != nil {
+		t.Fatal(err)
+	}
+	return b.Build(context.Background())
+}
+
+// fees returns the fee the transaction pays, spending the cells, and its minimum fee at the default fee rate.
+func fees(t *testing.T, tx *types.Transaction, cells []*indexer.LiveCell) (uint64, uint64) {
+	var inputs, outputs uint64
+	for _, input := range tx.Inputs {
+		for _, cell := range cells {
+			if *cell.OutPoint == *input.PreviousOutput {
+				inputs += cell.Output.Capacity
+			}
+		}
+	}
+	for _, output := range tx.Outputs {
+		outputs += output.Capacity
+	}
+	minimum, err := transaction.MinimumFee(tx, DefaultFeeRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inputs - outputs, minimum
+}
+
+func TestBuildChange(t *testing.T) {
+	cells := []*indexer.LiveCell{liveCell(t, 0, 200*100000000)}
+
+	// 100 CKB are left, a change cell of the sender takes them less the fee.
+	tx, groups, err := transfer(t, cells, 100*100000000, senderAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee, minimum := fees(t, tx, cells)
+	if len(tx.Outputs) != 2 || !bytes.Equal(tx.Outputs[1].Lock.Args, cells[0].Output.Lock.Args) || len(groups) != 1 {
+		t.Fatalf("transfer has %d outputs in %d groups", len(tx.Outputs), len(groups))
+	}
+	if fee != minimum || tx.Outputs[1].Capacity < 61*100000000 {
+		t.Errorf("change is %d with fee %d, want the minimum fee %d", tx.Outputs[1].Capacity, fee, minimum)
+	}
+
+	// 50 CKB are left, below the 61 CKB of a change cell, and paid as fee.
+	tx, _, err = transfer(t, cells, 150*100000000, senderAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee, minimum = fees(t, tx, cells)
+	if len(tx.Outputs) != 1 || fee != 50*100000000 || fee < minimum {
+		t.Errorf("transfer has %d outputs with fee %d", len(tx.Outputs), fee)
+	}
+
+	// the exact amount of the cell less the fee needs no change.
+	tx, _, err = transfer(t, cells, 200*100000000-minimum, senderAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee, minimum = fees(t, tx, cells)
+	if len(tx.Outputs) != 1 || fee != minimum {
+		t.Errorf("exact transfer has %d outputs with fee %d, want the minimum fee %d", len(tx.Outputs), fee, minimum)
+	}
+}
+
+func TestBuildInsufficientCapacity(t *testing.T) {
+	cells := []*indexer.LiveCell{liveCell(t, 0, 100*100000000)}
+	if _, _, err := transfer(t, cells, 100*100000000, senderAddress); !errors.Is(err, collector.ErrInsufficientCapacity) {
+		t.Errorf("transferred the whole cell without a fee with %v", err)
+	}
+	if _, _, err := transfer(t, cells, 200*100000000, senderAddress); !errors.Is(err, collector.ErrInsufficientCapacity) {
+		t.Errorf("transferred above the capacity with %v", err)
+	}
+}
+
+func TestBuildSenders(t *testing.T) {
+	cells := []*indexer.LiveCell{liveCell(t, 0, 100*100000000), addressCell(t, otherAddress, 1, 100*100000000)}
+	tx, groups, err := transfer(t, cells, 120*100000000, senderAddress, otherAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Inputs) != 2 || len(groups) != 2 {
+		t.Fatalf("transfer spends %d inputs in %d groups", len(tx.Inputs), len(groups))
+	}
+	// the change goes to the first sender.
+	fee, minimum := fees(t, tx, cells)
+	if len(tx.Outputs) != 2 || !bytes.Equal(tx.Outputs[1].Lock.Args, cells[0].Output.Lock.Args) || fee != minimum {
+		t.Errorf("transfer has %d outputs with fee %d, want the minimum fee %d", len(tx.Outputs), fee, minimum)
+	}
+}
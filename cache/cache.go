@@ -0,0 +1,442 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+const (
+	// DefaultMaxRollback is the number of recent blocks kept to undo a chain reorganization.
+	DefaultMaxRollback = 100
+
+	pageSize = 1000
+)
+
+var _ indexer.Client = (*CellCache)(nil)
+
+// CellCache keeps the live cells of registered search keys in memory and follows new blocks from the node
+// to update them. It answers GetCells and GetCellsCapacity of registered search keys locally and
+// forwards every other request to the indexer.
+type CellCache struct {
+	client rpc.Client
+	// MaxRollback is the number of recent blocks kept to undo a chain reorganization,
+	// the cache reloads from the indexer when a reorganization is deeper.
+	MaxRollback int
+	// Path is the file Run persists the cache to after every sync, empty to keep the cache in memory only.
+	Path string
+
+	syncMu sync.Mutex
+	mu     sync.RWMutex
+	tip    *indexer.TipHeader
+	keys   map[string]*entry
+	blocks []*blockDelta
+}
+
+type entry struct {
+	SearchKey *indexer.SearchKey           `json:"search_key"`
+	Cells     map[string]*indexer.LiveCell `json:"cells"`
+}
+
+type cellChange struct {
+	Key  string            `json:"key"`
+	Cell *indexer.LiveCell `json:"cell"`
+}
+
+// blockDelta records the cells created and consumed by a block, so the block can be rolled back.
+type blockDelta struct {
+	Number     uint64       `json:"number"`
+	Hash       types.Hash   `json:"hash"`
+	ParentHash types.Hash   `json:"parent_hash"`
+	Created    []cellChange `json:"created"`
+	Consumed   []cellChange `json:"consumed"`
+}
+
+type state struct {
+	Tip    *indexer.TipHeader `json:"tip"`
+	Keys   []*entry           `json:"keys"`
+	Blocks []*blockDelta      `json:"blocks"`
+}
+
+func NewCellCache(client rpc.Client) *CellCache {
+	return &CellCache{
+		client:      client,
+		MaxRollback: DefaultMaxRollback,
+		keys:        make(map[string]*entry),
+	}
+}
+
+// LoadCellCache restores a cache persisted to path, or returns an empty cache persisted to path when the file does not exist.
+func LoadCellCache(client rpc.Client, path string) (*CellCache, error) {
+	c := NewCellCache(client)
+	c.Path = path
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s state
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return nil, fmt.Errorf("decode cell cache %s error: %v", path, err)
+	}
+	c.tip = s.Tip
+	c.blocks = s.Blocks
+	for _, e := range s.Keys {
+		c.keys[keyID(e.SearchKey)] = e
+	}
+	return c, nil
+}
+
+// Save persists the cache to path.
+func (c *CellCache) Save(path string) error {
+	c.mu.RLock()
+	s := state{
+		Tip:    c.tip,
+		Blocks: c.blocks,
+	}
+	for _, e := range c.keys {
+		s.Keys = append(s.Keys, e)
+	}
+	data, err := json.Marshal(s)
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Register loads the live cells of the search key from the indexer and keeps them updated from then on.
+func (c *CellCache) Register(ctx context.Context, searchKey *indexer.SearchKey) error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
+	id := keyID(searchKey)
+	c.mu.RLock()
+	_, ok := c.keys[id]
+	c.mu.RUnlock()
+	if ok {
+		return nil
+	}
+
+	err := c.sync(ctx)
+	if err != nil {
+		return err
+	}
+	entries, tip, err := c.load(ctx, []*indexer.SearchKey{searchKey})
+	if err != nil {
+		return err
+	}
+	if c.tip == nil {
+		c.mu.Lock()
+		c.tip = tip
+		c.keys[id] = entries[0]
+		c.mu.Unlock()
+		return nil
+	}
+	if tip.BlockNumber > c.tip.BlockNumber {
+		return fmt.Errorf("indexer tip %d is ahead of cache tip %d, retry later", tip.BlockNumber, c.tip.BlockNumber)
+	}
+	if delta := c.delta(tip.BlockNumber); delta != nil && delta.Hash != tip.BlockHash {
+		return fmt.Errorf("indexer block %d %s is not on the cache chain, retry later", tip.BlockNumber, tip.BlockHash.String())
+	}
+
+	// bring the new search key from the indexer tip up to the cache tip
+	for number := tip.BlockNumber + 1; number <= c.tip.BlockNumber; number++ {
+		block, err := c.client.GetBlockByNumber(ctx, number)
+		if err != nil {
+			return err
+		}
+		delta := c.delta(number)
+		if delta != nil && delta.Hash != block.Header.Hash {
+			return fmt.Errorf("block %d %s is not on the cache chain, retry later", number, block.Header.Hash.String())
+		}
+		c.mu.Lock()
+		applyBlock(block, map[string]*entry{id: entries[0]}, delta)
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	c.keys[id] = entries[0]
+	c.mu.Unlock()
+	return nil
+}
+
+// Unregister drops the search key from the cache.
+func (c *CellCache) Unregister(searchKey *indexer.SearchKey) {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := keyID(searchKey)
+	delete(c.keys, id)
+	for _, delta := range c.blocks {
+		delta.Created = dropKey(delta.Created, id)
+		delta.Consumed = dropKey(delta.Consumed, id)
+	}
+}
+
+// Sync follows the node from the cache tip to the node tip, rolling back blocks that left the main chain.
+func (c *CellCache) Sync(ctx context.Context) error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+	return c.sync(ctx)
+}
+
+// Run syncs the cache every interval until the context is done or a sync fails,
+// persisting it after every sync when Path is set.
+func (c *CellCache) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := c.Sync(ctx)
+		if err != nil {
+			return err
+		}
+		if c.Path != "" {
+			err = c.Save(c.Path)
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *CellCache) sync(ctx context.Context) error {
+	if c.tip == nil {
+		return nil
+	}
+	tipNumber, err := c.client.GetTipBlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	for reloaded := false; ; {
+		onMainChain := false
+		if c.tip.BlockNumber <= tipNumber {
+			hash, err := c.client.GetBlockHash(ctx, c.tip.BlockNumber)
+			if err != nil {
+				return err
+			}
+			onMainChain = hash != nil && *hash == c.tip.BlockHash
+		}
+		if onMainChain {
+			break
+		}
+		if reloaded {
+			return fmt.Errorf("indexer block %d %s is not on the node main chain, retry later", c.tip.BlockNumber, c.tip.BlockHash.String())
+		}
+		reloaded, err = c.rollback(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	for number := c.tip.BlockNumber + 1; number <= tipNumber; number++ {
+		block, err := c.client.GetBlockByNumber(ctx, number)
+		if err != nil {
+			return err
+		}
+		if block.Header.ParentHash != c.tip.BlockHash {
+			// the chain reorganized while following it
+			return c.sync(ctx)
+		}
+
+		delta := &blockDelta{
+			Number:     block.Header.Number,
+			Hash:       block.Header.Hash,
+			ParentHash: block.Header.ParentHash,
+		}
+		c.mu.Lock()
+		applyBlock(block, c.keys, delta)
+		c.blocks = append(c.blocks, delta)
+		if len(c.blocks) > c.MaxRollback {
+			c.blocks = c.blocks[len(c.blocks)-c.MaxRollback:]
+		}
+		c.tip = &indexer.TipHeader{
+			BlockHash:   block.Header.Hash,
+			BlockNumber: block.Header.Number,
+		}
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+// rollback undoes the cache tip block, or reloads all search keys from the indexer when
+// the block is older than the recorded blocks. It reports whether the cache was reloaded.
+func (c *CellCache) rollback(ctx context.Context) (bool, error) {
+	c.mu.Lock()
+	if len(c.blocks) == 0 || c.blocks[len(c.blocks)-1].Hash != c.tip.BlockHash {
+		c.mu.Unlock()
+		return true, c.reload(ctx)
+	}
+	defer c.mu.Unlock()
+
+	delta := c.blocks[len(c.blocks)-1]
+	c.blocks = c.blocks[:len(c.blocks)-1]
+	for _, change := range delta.Created {
+		if e, ok := c.keys[change.Key]; ok {
+			delete(e.Cells, outPointKey(change.Cell.OutPoint))
+		}
+	}
+	for _, change := range delta.Consumed {
+		if e, ok := c.keys[change.Key]; ok {
+			e.Cells[outPointKey(change.Cell.OutPoint)] = change.Cell
+		}
+	}
+	c.tip = &indexer.TipHeader{
+		BlockHash:   delta.ParentHash,
+		BlockNumber: delta.Number - 1,
+	}
+	return false, nil
+}
+
+func (c *CellCache) reload(ctx context.Context) error {
+	c.mu.RLock()
+	keys := make([]*indexer.SearchKey, 0, len(c.keys))
+	for _, e := range c.keys {
+		keys = append(keys, e.SearchKey)
+	}
+	c.mu.RUnlock()
+
+	entries, tip, err := c.load(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = make(map[string]*entry, len(entries))
+	for _, e := range entries {
+		c.keys[keyID(e.SearchKey)] = e
+	}
+	c.blocks = nil
+	c.tip = tip
+	return nil
+}
+
+// load reads the live cells of all search keys from the indexer at the same indexer tip.
+func (c *CellCache) load(ctx context.Context, keys []*indexer.SearchKey) ([]*entry, *indexer.TipHeader, error) {
+	for restarts := 0; ; restarts++ {
+		snapshot, err := rpc.NewSnapshot(ctx, c.client, rpc.SnapshotPolicyFail)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries, err := loadEntries(ctx, snapshot, keys)
+		if err == nil {
+			return entries, snapshot.Tip(), nil
+		}
+		if !errors.Is(err, rpc.ErrSnapshotChanged) || restarts >= rpc.DefaultMaxRestarts {
+			return nil, nil, err
+		}
+	}
+}
+
+func loadEntries(ctx context.Context, snapshot *rpc.Snapshot, keys []*indexer.SearchKey) ([]*entry, error) {
+	entries := make([]*entry, len(keys))
+	for i, key := range keys {
+		cells, err := snapshot.GetAllCells(ctx, key, indexer.SearchOrderAsc, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = &entry{
+			SearchKey: key,
+			Cells:     make(map[string]*indexer.LiveCell, len(cells)),
+		}
+		for _, cell := range cells {
+			entries[i].Cells[outPointKey(cell.OutPoint)] = cell
+		}
+	}
+	return entries, nil
+}
+
+func (c *CellCache) delta(number uint64) *blockDelta {
+	for _, delta := range c.blocks {
+		if delta.Number == number {
+			return delta
+		}
+	}
+	return nil
+}
+
+// applyBlock updates the entries with the cells consumed and created by the block, recording them in delta when it is not nil.
+func applyBlock(block *types.Block, entries map[string]*entry, delta *blockDelta) {
+	for txIndex, tx := range block.Transactions {
+		for _, input := range tx.Inputs {
+			op := outPointKey(input.PreviousOutput)
+			for id, e := range entries {
+				if cell, ok := e.Cells[op]; ok {
+					delete(e.Cells, op)
+					if delta != nil {
+						delta.Consumed = append(delta.Consumed, cellChange{Key: id, Cell: cell})
+					}
+				}
+			}
+		}
+		for index, output := range tx.Outputs {
+			for id, e := range entries {
+				if !e.SearchKey.Match(output) {
+					continue
+				}
+				cell := &indexer.LiveCell{
+					BlockNumber: block.Header.Number,
+					OutPoint: &types.OutPoint{
+						TxHash: tx.Hash,
+						Index:  uint(index),
+					},
+					Output:     output,
+					OutputData: tx.OutputsData[index],
+					TxIndex:    uint(txIndex),
+				}
+				e.Cells[outPointKey(cell.OutPoint)] = cell
+				if delta != nil {
+					delta.Created = append(delta.Created, cellChange{Key: id, Cell: cell})
+				}
+			}
+		}
+	}
+}
+
+func dropKey(changes []cellChange, id string) []cellChange {
+	result := changes[:0]
+	for _, change := range changes {
+		if change.Key != id {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+func keyID(key *indexer.SearchKey) string {
+	return fmt.Sprintf("%s:%s:%x:%s:%d", key.Script.CodeHash.String(), key.Script.HashType, key.Script.Args, key.ScriptType, key.ArgsLen)
+}
+
+func outPointKey(outPoint *types.OutPoint) string {
+	return fmt.Sprintf("%s:%d", outPoint.TxHash.String(), outPoint.Index)
+}
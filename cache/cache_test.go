@@ -0,0 +1,387 @@
+package cache
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+var lockCode = types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8")
+
+// indexerService answers get_tip and get_cells over JSON-RPC from a list of live cells indexed up to a block,
+// its cursors are positions in the matching cells.
+type indexerService struct {
+	tip   *types.Header
+	cells []*indexer.LiveCell
+	calls int
+}
+
+type searchKeyParam struct {
+	Script struct {
+		CodeHash types.Hash           `json:"code_hash"`
+		HashType types.ScriptHashType `json:"hash_type"`
+		Args     hexutil.Bytes        `json:"args"`
+	} `json:"script"`
+	ScriptType indexer.ScriptType `json:"script_type"`
+}
+
+func (s *indexerService) Tip() map[string]interface{} {
+	return map[string]interface{}{"block_hash": s.tip.Hash, "block_number": hexutil.Uint64(s.tip.Number)}
+}
+
+func (s *indexerService) Cells(key searchKeyParam, order indexer.SearchOrder, limit hexutil.Uint64, cursor *string) (map[string]interface{}, error) {
+	s.calls++
+	searchKey := &indexer.SearchKey{
+		Script:     &types.Script{CodeHash: key.Script.CodeHash, HashType: key.Script.HashType, Args: key.Script.Args},
+		ScriptType: key.ScriptType,
+	}
+	var cells []*indexer.LiveCell
+	for _, cell := range s.cells {
+		if searchKey.Match(cell.Output) {
+			cells = append(cells, cell)
+		}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if order == indexer.SearchOrderDesc {
+			return indexer.CompareLiveCells(cells[i], cells[j]) > 0
+		}
+		return indexer.CompareLiveCells(cells[i], cells[j]) < 0
+	})
+	start := 0
+	if cursor != nil {
+		var err error
+		start, err = strconv.Atoi(*cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+	end := start + int(limit)
+	if end > len(cells) {
+		end = len(cells)
+	}
+	objects := make([]interface{}, 0, end-start)
+	for _, cell := range cells[start:end] {
+		lock := cell.Output.Lock
+		objects = append(objects, map[string]interface{}{
+			"block_number": hexutil.Uint64(cell.BlockNumber),
+			"out_point":    map[string]interface{}{"tx_hash": cell.OutPoint.TxHash, "index": hexutil.Uint(cell.OutPoint.Index)},
+			"output": map[string]interface{}{
+				"capacity": hexutil.Uint64(cell.Output.Capacity),
+				"lock":     map[string]interface{}{"code_hash": lock.CodeHash, "hash_type": lock.HashType, "args": hexutil.Bytes(lock.Args)},
+			},
+			"output_data": hexutil.Bytes(cell.OutputData),
+			"tx_index":    hexutil.Uint(cell.TxIndex),
+		})
+	}
+	return map[string]interface{}{"last_cursor": strconv.Itoa(end), "objects": objects}, nil
+}
+
+// chainClient follows a main chain of blocks and forwards the indexer requests to the indexer service,
+// leaving the other methods unimplemented.
+type chainClient struct {
+	rpc.Client
+	indexer indexer.Client
+	blocks  []*types.Block
+}
+
+func (c *chainClient) GetTipBlockNumber(ctx context.Context) (uint64, error) {
+	return uint64(len(c.blocks) - 1), nil
+}
+
+func (c *chainClient) GetBlockHash(ctx context.Context, number uint64) (*types.Hash, error) {
+	if number >= uint64(len(c.blocks)) {
+		return nil, nil
+	}
+	return &c.blocks[number].Header.Hash, nil
+}
+
+func (c *chainClient) GetBlockByNumber(ctx context.Context, number uint64) (*types.Block, error) {
+	return c.blocks[number], nil
+}
+
+func (c *chainClient) GetTip(ctx context.Context) (*indexer.TipHeader, error) {
+	return c.indexer.GetTip(ctx)
+}
+
+func (c *chainClient) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	return c.indexer.GetCells(ctx, searchKey, order, limit, afterCursor)
+}
+
+func (c *chainClient) BatchIndexer(ctx context.Context, batch *indexer.BatchRequest) error {
+	return c.indexer.BatchCall(ctx, batch)
+}
+
+func lock(owner byte) *types.Script {
+	return &types.Script{CodeHash: lockCode, HashType: types.HashTypeType, Args: []byte{owner}}
+}
+
+func searchKey(owner byte) *indexer.SearchKey {
+	return &indexer.SearchKey{Script: lock(owner), ScriptType: indexer.ScriptTypeLock}
+}
+
+// hash returns a hash starting with the fork, the block number and the kind of hashed object.
+func hash(fork byte, number uint64, kind byte) types.Hash {
+	var h types.Hash
+	h[0], h[1], h[2] = fork, byte(number), kind
+	return h
+}
+
+// block returns a block on top of parent with a transaction spending the inputs into 100 CKB cells of the owners.
+// The fork byte tells apart blocks of the same number.
+func block(parent *types.Header, fork byte, inputs []*types.OutPoint, owners ...byte) *types.Block {
+	header := &types.Header{Hash: hash(fork, 0, 0)}
+	if parent != nil {
+		header = &types.Header{
+			Number:     parent.Number + 1,
+			Hash:       hash(fork, parent.Number+1, 0),
+			ParentHash: parent.Hash,
+		}
+	}
+	tx := &types.Transaction{Hash: hash(fork, header.Number, 1)}
+	for _, input := range inputs {
+		tx.Inputs = append(tx.Inputs, &types.CellInput{PreviousOutput: input})
+	}
+	for _, owner := range owners {
+		tx.Outputs = append(tx.Outputs, &types.CellOutput{Capacity: 100 * 100000000, Lock: lock(owner)})
+		tx.OutputsData = append(tx.OutputsData, []byte{})
+	}
+	return &types.Block{Header: header, Transactions: []*types.Transaction{tx}}
+}
+
+// indexed returns the live cells created by the blocks which are not spent by them, as the indexer reports them.
+func indexed(blocks []*types.Block) []*indexer.LiveCell {
+	live := make(map[string]*indexer.LiveCell)
+	entries := map[string]*entry{"": {SearchKey: &indexer.SearchKey{Script: &types.Script{CodeHash: lockCode, HashType: types.HashTypeType}}, Cells: live}}
+	for _, b := range blocks {
+		applyBlock(b, entries, nil)
+	}
+	cells := make([]*indexer.LiveCell, 0, len(live))
+	for _, cell := range live {
+		cells = append(cells, cell)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		return indexer.CompareLiveCells(cells[i], cells[j]) < 0
+	})
+	return cells
+}
+
+// testChain returns a chain of three blocks creating cells of owners 1, 2 and 1, indexed up to its tip.
+func testChain() (*chainClient, *indexerService) {
+	genesis := block(nil, 0, nil)
+	one := block(genesis.Header, 0, nil, 1, 2)
+	two := block(one.Header, 0, nil, 1)
+	blocks := []*types.Block{genesis, one, two}
+	service := &indexerService{tip: two.Header, cells: indexed(blocks)}
+	server := ethrpc.NewServer()
+	if err := server.RegisterName("get", service); err != nil {
+		panic(err)
+	}
+	return &chainClient{indexer: indexer.NewClient(ethrpc.DialInProc(server)), blocks: blocks}, service
+}
+
+func outPoint(b *types.Block, index uint) *types.OutPoint {
+	return &types.OutPoint{TxHash: b.Transactions[0].Hash, Index: index}
+}
+
+// cached returns the block number, index and fork of the cached cells of the owner in ascending order,
+// paging one cell at a time.
+func cached(t *testing.T, c *CellCache, owner byte) []string {
+	var cells []string
+	cursor := ""
+	for {
+		page, err := c.GetCells(context.Background(), searchKey(owner), indexer.SearchOrderAsc, 1, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Objects) == 0 {
+			return cells
+		}
+		cell := page.Objects[0]
+		cells = append(cells, strconv.FormatUint(cell.BlockNumber, 10)+":"+strconv.Itoa(int(cell.OutPoint.Index))+":"+strconv.Itoa(int(cell.OutPoint.TxHash[0])))
+		cursor = page.LastCursor
+	}
+}
+
+func equal(a []string, b ...string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRegisterAndSync(t *testing.T) {
+	client, service := testChain()
+	c := NewCellCache(client)
+	if err := c.Register(context.Background(), searchKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := cached(t, c, 1); !equal(got, "1:0:0", "2:0:0") {
+		t.Errorf("registered cells are %v", got)
+	}
+
+	// the next block spends the first cell of owner 1 and creates cells of owners 1 and 2.
+	client.blocks = append(client.blocks, block(client.blocks[2].Header, 0, []*types.OutPoint{outPoint(client.blocks[1], 0)}, 2, 1))
+	calls := service.calls
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := cached(t, c, 1); !equal(got, "2:0:0", "3:1:0") {
+		t.Errorf("synced cells are %v", got)
+	}
+	if service.calls != calls {
+		t.Errorf("cache queried the indexer %d times", service.calls-calls)
+	}
+	capacity, err := c.GetCellsCapacity(context.Background(), searchKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tip, err := c.GetTip(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capacity.Capacity != 200*100000000 || capacity.BlockNumber != 3 || tip.BlockNumber != 3 || tip.BlockHash != client.blocks[3].Header.Hash {
+		t.Errorf("capacity is %+v at tip %+v", capacity, tip)
+	}
+
+	// the indexer is still at block 2, the new search key is brought up to the cache tip.
+	if err := c.Register(context.Background(), searchKey(2)); err != nil {
+		t.Fatal(err)
+	}
+	if got := cached(t, c, 2); !equal(got, "1:1:0", "3:0:0") {
+		t.Errorf("cells registered behind the cache tip are %v", got)
+	}
+
+	// unregistered search keys are forwarded to the indexer.
+	cells, err := c.GetCells(context.Background(), searchKey(3), indexer.SearchOrderAsc, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cells.Objects) != 0 || service.calls == calls {
+		t.Errorf("got %d cells of an unregistered search key", len(cells.Objects))
+	}
+	c.Unregister(searchKey(2))
+	calls = service.calls
+	if _, err := c.GetCells(context.Background(), searchKey(2), indexer.SearchOrderAsc, 10, ""); err != nil || service.calls == calls {
+		t.Errorf("unregistered search key was answered by the cache with %v", err)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	client, _ := testChain()
+	c := NewCellCache(client)
+	if err := c.Register(context.Background(), searchKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	client.blocks = append(client.blocks, block(client.blocks[2].Header, 0, []*types.OutPoint{outPoint(client.blocks[1], 0)}, 1))
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// blocks 3 and 4 of a fork replace block 3: its spent cell comes back and its created cell is gone.
+	forked := block(client.blocks[2].Header, 1, nil, 2)
+	client.blocks = append(client.blocks[:3], forked, block(forked.Header, 1, nil, 1))
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := cached(t, c, 1); !equal(got, "1:0:0", "2:0:0", "4:0:1") {
+		t.Errorf("cells after the reorganization are %v", got)
+	}
+}
+
+func TestReload(t *testing.T) {
+	client, service := testChain()
+	c := NewCellCache(client)
+	c.MaxRollback = 0
+	if err := c.Register(context.Background(), searchKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	client.blocks = append(client.blocks, block(client.blocks[2].Header, 0, nil, 1))
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// no block is kept to roll back, the cache reloads from the indexer once it follows the fork.
+	client.blocks = append(client.blocks[:3], block(client.blocks[2].Header, 1, []*types.OutPoint{outPoint(client.blocks[2], 0)}, 2))
+	service.tip, service.cells = client.blocks[3].Header, indexed(client.blocks)
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := cached(t, c, 1); !equal(got, "1:0:0") {
+		t.Errorf("reloaded cells are %v", got)
+	}
+
+	// an indexer off the node main chain is an error.
+	service.tip = block(client.blocks[2].Header, 2, nil).Header
+	client.blocks = append(client.blocks[:3], block(client.blocks[2].Header, 3, nil))
+	if err := c.Sync(context.Background()); err == nil {
+		t.Error("synced from an indexer off the main chain")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	client, _ := testChain()
+	c := NewCellCache(client)
+	if err := c.Register(context.Background(), searchKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	client.blocks = append(client.blocks, block(client.blocks[2].Header, 0, []*types.OutPoint{outPoint(client.blocks[1], 0)}, 1))
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cells.json")
+	empty, err := LoadCellCache(client, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip, err := empty.GetTip(context.Background()); err != nil || tip.BlockNumber != 2 {
+		t.Errorf("missing cache file loaded tip %+v, %v", tip, err)
+	}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCellCache(client, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cached(t, loaded, 1); !equal(got, "2:0:0", "3:0:0") {
+		t.Errorf("loaded cells are %v", got)
+	}
+
+	// the loaded cache still rolls back the recorded block.
+	client.blocks = append(client.blocks[:3], block(client.blocks[2].Header, 1, nil, 2))
+	if err := loaded.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := cached(t, loaded, 1); !equal(got, "1:0:0", "2:0:0") {
+		t.Errorf("cells of the loaded cache after the reorganization are %v", got)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCellCache(client, path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("corrupted cache file loaded with %v", err)
+	}
+}
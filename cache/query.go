@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+)
+
+func (c *CellCache) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	cells, ok := c.cells(searchKey)
+	if !ok {
+		return c.client.GetCells(ctx, searchKey, order, limit, afterCursor)
+	}
+	return page(cells, order, limit, afterCursor)
+}
+
+func (c *CellCache) GetCellsCapacity(ctx context.Context, searchKey *indexer.SearchKey) (*indexer.Capacity, error) {
+	capacity, ok := c.capacity(searchKey)
+	if !ok {
+		return c.client.GetCellsCapacity(ctx, searchKey)
+	}
+	return capacity, nil
+}
+
+func (c *CellCache) GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.Transactions, error) {
+	return c.client.GetTransactions(ctx, searchKey, order, limit, afterCursor)
+}
+
+// GetTip returns the block the cache is synced to, or the indexer tip when nothing is registered yet.
+func (c *CellCache) GetTip(ctx context.Context) (*indexer.TipHeader, error) {
+	c.mu.RLock()
+	tip := c.tip
+	c.mu.RUnlock()
+	if tip == nil {
+		return c.client.GetTip(ctx)
+	}
+	return &indexer.TipHeader{
+		BlockHash:   tip.BlockHash,
+		BlockNumber: tip.BlockNumber,
+	}, nil
+}
+
+func (c *CellCache) BatchGetCells(ctx context.Context, batch []indexer.BatchGetCellsItem) error {
+	var remote []int
+	for i := range batch {
+		item := &batch[i]
+		cells, ok := c.cells(item.SearchKey)
+		if !ok {
+			remote = append(remote, i)
+			continue
+		}
+		item.Result, item.Error = page(cells, item.Order, item.Limit, item.AfterCursor)
+	}
+	if len(remote) == 0 {
+		return nil
+	}
+
+	items := make([]indexer.BatchGetCellsItem, len(remote))
+	for i, index := range remote {
+		items[i] = batch[index]
+	}
+	err := c.client.BatchGetCells(ctx, items)
+	if err != nil {
+		return err
+	}
+	for i, index := range remote {
+		batch[index] = items[i]
+	}
+	return nil
+}
+
+func (c *CellCache) BatchGetCellsCapacity(ctx context.Context, batch []indexer.BatchGetCellsCapacityItem) error {
+	var remote []int
+	for i := range batch {
+		capacity, ok := c.capacity(batch[i].SearchKey)
+		if !ok {
+			remote = append(remote, i)
+			continue
+		}
+		batch[i].Result = capacity
+	}
+	if len(remote) == 0 {
+		return nil
+	}
+
+	items := make([]indexer.BatchGetCellsCapacityItem, len(remote))
+	for i, index := range remote {
+		items[i] = batch[index]
+	}
+	err := c.client.BatchGetCellsCapacity(ctx, items)
+	if err != nil {
+		return err
+	}
+	for i, index := range remote {
+		batch[index] = items[i]
+	}
+	return nil
+}
+
+// BatchCall forwards the batch request to the indexer.
+func (c *CellCache) BatchCall(ctx context.Context, batch *indexer.BatchRequest) error {
+	return c.client.BatchIndexer(ctx, batch)
+}
+
+func (c *CellCache) Close() {
+	c.client.Close()
+}
+
+// cells returns a copy of the live cells of a registered search key.
+func (c *CellCache) cells(searchKey *indexer.SearchKey) ([]*indexer.LiveCell, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.keys[keyID(searchKey)]
+	if !ok {
+		return nil, false
+	}
+	cells := make([]*indexer.LiveCell, 0, len(e.Cells))
+	for _, cell := range e.Cells {
+		cells = append(cells, cell)
+	}
+	return cells, true
+}
+
+func (c *CellCache) capacity(searchKey *indexer.SearchKey) (*indexer.Capacity, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.keys[keyID(searchKey)]
+	if !ok {
+		return nil, false
+	}
+	result := &indexer.Capacity{
+		BlockHash:   c.tip.BlockHash,
+		BlockNumber: c.tip.BlockNumber,
+	}
+	for _, cell := range e.Cells {
+		result.Capacity += cell.Output.Capacity
+	}
+	return result, true
+}
+
+// page returns the cells after the cursor in the given order. Cursors encode the position of the
+// last returned cell and are only meaningful to the cache.
+func page(cells []*indexer.LiveCell, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	desc := order == indexer.SearchOrderDesc
+	sort.Slice(cells, func(i, j int) bool {
+		if desc {
+			return indexer.CompareLiveCells(cells[i], cells[j]) > 0
+		}
+		return indexer.CompareLiveCells(cells[i], cells[j]) < 0
+	})
+
+	start := 0
+	if afterCursor != "" {
+		position, err := decodeCursor(afterCursor)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(cells), func(i int) bool {
+			if desc {
+				return comparePosition(cells[i], position) < 0
+			}
+			return comparePosition(cells[i], position) > 0
+		})
+	}
+	end := len(cells)
+	if uint64(end-start) > limit {
+		end = start + int(limit)
+	}
+
+	result := &indexer.LiveCells{
+		LastCursor: afterCursor,
+		Objects:    cells[start:end],
+	}
+	if end > start {
+		result.LastCursor = encodeCursor(cells[end-1])
+	}
+	return result, nil
+}
+
+type position struct {
+	blockNumber uint64
+	txIndex     uint
+	index       uint
+}
+
+func encodeCursor(cell *indexer.LiveCell) string {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[0:8], cell.BlockNumber)
+	binary.BigEndian.PutUint32(b[8:12], uint32(cell.TxIndex))
+	binary.BigEndian.PutUint32(b[12:16], uint32(cell.OutPoint.Index))
+	return hexutil.Encode(b)
+}
+
+func decodeCursor(cursor string) (*position, error) {
+	b, err := hexutil.Decode(cursor)
+	if err != nil || len(b) != 16 {
+		return nil, fmt.Errorf("invalid cell cache cursor %s", cursor)
+	}
+	return &position{
+		blockNumber: binary.BigEndian.Uint64(b[0:8]),
+		txIndex:     uint(binary.BigEndian.Uint32(b[8:12])),
+		index:       uint(binary.BigEndian.Uint32(b[12:16])),
+	}, nil
+}
+
+func comparePosition(cell *indexer.LiveCell, p *position) int {
+	switch {
+	case cell.BlockNumber != p.blockNumber:
+		if cell.BlockNumber < p.blockNumber {
+			return -1
+		}
+		return 1
+	case cell.TxIndex != p.txIndex:
+		if cell.TxIndex < p.txIndex {
+			return -1
+		}
+		return 1
+	case cell.OutPoint.Index != p.index:
+		if cell.OutPoint.Index < p.index {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,242 @@
+// Package celldep resolves the cell deps of the scripts a transaction runs, from the system script registry or from
+// the code cells of deploy transactions, and verifies cell deps by expanding their dep groups.
+package celldep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+)
+
+const statusLive = "live"
+
+// ErrNotFound is returned when neither the registry nor the scanned transactions hold the code of a script.
+var ErrNotFound = errors.New("cell dep not found")
+
+// Resolver finds the cell deps of scripts. Registered deployments come first, the outputs of Transactions are scanned
+// for the code of the other scripts: the cell whose data hash is the code hash of data scripts, or whose type script
+// hash, such as a type id, is the code hash of type scripts.
+type Resolver struct {
+	client   rpc.Client
+	registry *systemscript.Registry
+	// Transactions are scanned in order for the code cells of the scripts the registry lacks, such as the
+	// transactions deploying them.
+	Transactions []types.Hash
+
+	found map[string]*types.CellDep
+}
+
+func NewResolver(client rpc.Client, registry *systemscript.Registry) *Resolver {
+	return &Resolver{
+		client:   client,
+		registry: registry,
+		found:    make(map[string]*types.CellDep),
+	}
+}
+
+// Resolve adds the cell deps the transaction lacks for the locks and types of its inputs and the types of its outputs.
+// The inputs are looked up with GetLiveCell, and the cells its deps load are expanded to skip the scripts whose code
+// they already hold.
+func (r *Resolver) Resolve(ctx context.Context, tx *types.Transaction) error {
+	scripts, err := r.scripts(ctx, tx)
+	if err != nil {
+		return err
+	}
+	var loaded []*types.CellInfo
+	for _, dep := range tx.CellDeps {
+		cells, err := r.depCells(ctx, dep)
+		if err != nil {
+			return err
+		}
+		loaded = append(loaded, cells...)
+	}
+	for _, script := range scripts {
+		ok, err := loadedBy(script, loaded)
+		if err != nil {
+			return err
+		}
+		if ok {
+			continue
+		}
+		dep, err := r.CellDep(ctx, script)
+		if err != nil {
+			return err
+		}
+		if contains(tx.CellDeps, dep) {
+			continue
+		}
+		tx.CellDeps = append(tx.CellDeps, dep)
+		cells, err := r.depCells(ctx, dep)
+		if err != nil {
+			return err
+		}
+		loaded = append(loaded, cells...)
+	}
+	return nil
+}
+
+// CellDep returns the cell dep of the code of the script: the registered cell dep of a well-known script, code or dep
+// group, or a code dep of the cell found in the scanned transactions.
+func (r *Resolver) CellDep(ctx context.Context, script *types.Script) (*types.CellDep, error) {
+	if name, ok := r.registry.Find(script); ok {
+		deployment, err := r.registry.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return deployment.CellDep, nil
+	}
+	key := codeKey(script)
+	if dep, ok := r.found[key]; ok {
+		return dep, nil
+	}
+	for _, hash := range r.Transactions {
+		tx, err := r.client.GetTransaction(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		for i, output := range tx.Transaction.Outputs {
+			ok, err := loadsCode(script, output, tx.Transaction.OutputsData[i])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				dep := &types.CellDep{
+					OutPoint: &types.OutPoint{
+						TxHash: hash,
+						Index:  uint(i),
+					},
+					DepType: types.DepTypeCode,
+				}
+				r.found[key] = dep
+				return dep, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("code %s of hash type %s: %w", script.CodeHash.String(), script.HashType, ErrNotFound)
+}
+
+// Expand returns the out points of the cells the dep loads: the dep cell of a code dep, or the cells the live dep
+// group cell lists.
+func (r *Resolver) Expand(ctx context.Context, dep *types.CellDep) ([]*types.OutPoint, error) {
+	if dep.DepType == types.DepTypeCode {
+		return []*types.OutPoint{dep.OutPoint}, nil
+	}
+	cell, err := r.liveCell(ctx, dep.OutPoint, true)
+	if err != nil {
+		return nil, err
+	}
+	return molecule.DeserializeOutPointVec(cell.Data.Content)
+}
+
+// Verify checks that the cells the dep loads are live and one of them holds the code of the script.
+func (r *Resolver) Verify(ctx context.Context, dep *types.CellDep, script *types.Script) error {
+	cells, err := r.depCells(ctx, dep)
+	if err != nil {
+		return err
+	}
+	found, err := loadedBy(script, cells)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("cell dep %s#%d does not load code %s", dep.OutPoint.TxHash.String(), dep.OutPoint.Index, script.CodeHash.String())
+	}
+	return nil
+}
+
+// depCells returns the live cells the dep loads, with their data.
+func (r *Resolver) depCells(ctx context.Context, dep *types.CellDep) ([]*types.CellInfo, error) {
+	outPoints, err := r.Expand(ctx, dep)
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]*types.CellInfo, len(outPoints))
+	for i, outPoint := range outPoints {
+		cells[i], err = r.liveCell(ctx, outPoint, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cells, nil
+}
+
+// scripts returns the scripts the transaction runs, one per code.
+func (r *Resolver) scripts(ctx context.Context, tx *types.Transaction) ([]*types.Script, error) {
+	var scripts []*types.Script
+	seen := make(map[string]bool)
+	add := func(script *types.Script) {
+		if script == nil || seen[codeKey(script)] {
+			return
+		}
+		seen[codeKey(script)] = true
+		scripts = append(scripts, script)
+	}
+	for _, input := range tx.Inputs {
+		cell, err := r.liveCell(ctx, input.PreviousOutput, false)
+		if err != nil {
+			return nil, err
+		}
+		add(cell.Output.Lock)
+		add(cell.Output.Type)
+	}
+	for _, output := range tx.Outputs {
+		add(output.Type)
+	}
+	return scripts, nil
+}
+
+func (r *Resolver) liveCell(ctx context.Context, outPoint *types.OutPoint, withData bool) (*types.CellInfo, error) {
+	cell, err := r.client.GetLiveCell(ctx, outPoint, withData)
+	if err != nil {
+		return nil, err
+	}
+	if cell.Status != statusLive || cell.Cell == nil {
+		return nil, fmt.Errorf("cell %s#%d is %s", outPoint.TxHash.String(), outPoint.Index, cell.Status)
+	}
+	if withData && cell.Cell.Data == nil {
+		return nil, fmt.Errorf("cell %s#%d has no data", outPoint.TxHash.String(), outPoint.Index)
+	}
+	return cell.Cell, nil
+}
+
+// loadsCode reports whether the cell holds the code of the script.
+func loadsCode(script *types.Script, output *types.CellOutput, data []byte) (bool, error) {
+	if script.HashType == types.HashTypeType {
+		if output.Type == nil {
+			return false, nil
+		}
+		hash, err := molecule.ScriptHash(output.Type)
+		return hash == script.CodeHash, err
+	}
+	hash, err := molecule.Hash(data)
+	return hash == script.CodeHash, err
+}
+
+// loadedBy reports whether one of the cells holds the code of the script.
+func loadedBy(script *types.Script, cells []*types.CellInfo) (bool, error) {
+	for _, cell := range cells {
+		ok, err := loadsCode(script, cell.Output, cell.Data.Content)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func codeKey(script *types.Script) string {
+	return script.CodeHash.String() + string(script.HashType)
+}
+
+func contains(deps []*types.CellDep, dep *types.CellDep) bool {
+	for _, d := range deps {
+		if d.DepType == dep.DepType && d.OutPoint.TxHash == dep.OutPoint.TxHash && d.OutPoint.Index == dep.OutPoint.Index {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package celldep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+)
+
+const (
+	mainnetGenesisTx  = "0xe2fb199810d49a4d8beec56718ba2593b665db9d52299a0f9e6e75416d73ff5c"
+	mainnetDepGroupTx = "0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c"
+	deployTx          = "0x4bb9b6b2d37ac4fe8fd2be4ac3bf12e2a4d52baf9f0dcfce9da6bc4a7e6ab3b6"
+	typeIDCodeHash    = "0x00000000000000000000000000000000000000000000000000545950455f4944"
+)
+
+// chainClient serves the live cells and transactions of a fixed chain, leaving the other methods unimplemented.
+type chainClient struct {
+	rpc.Client
+	cells        map[string]*types.CellInfo
+	transactions map[types.Hash]*types.Transaction
+}
+
+func newChainClient() *chainClient {
+	return &chainClient{
+		cells:        make(map[string]*types.CellInfo),
+		transactions: make(map[types.Hash]*types.Transaction),
+	}
+}
+
+func (c *chainClient) GetLiveCell(ctx context.Context, outPoint *types.OutPoint, withData bool) (*types.CellWithStatus, error) {
+	cell, ok := c.cells[fmt.Sprintf("%s#%d", outPoint.TxHash.String(), outPoint.Index)]
+	if !ok {
+		return &types.CellWithStatus{Status: "unknown"}, nil
+	}
+	return &types.CellWithStatus{Cell: cell, Status: statusLive}, nil
+}
+
+func (c *chainClient) GetTransaction(ctx context.Context, hash types.Hash) (*types.TransactionWithStatus, error) {
+	tx, ok := c.transactions[hash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &types.TransactionWithStatus{Transaction: tx}, nil
+}
+
+func (c *chainClient) addCell(txHash string, index uint, output *types.CellOutput, data []byte) *types.OutPoint {
+	c.cells[fmt.Sprintf("%s#%d", txHash, index)] = &types.CellInfo{
+		Output: output,
+		Data:   &types.CellData{Content: data},
+	}
+	return &types.OutPoint{TxHash: types.HexToHash(txHash), Index: index}
+}
+
+// mainnetClient serves the mainnet secp256k1-blake160 dep group, its script and secp256k1 data cells, and an input
+// locked by each of the locks.
+func mainnetClient(locks ...*types.Script) *chainClient {
+	c := newChainClient()
+	c.addCell(mainnetGenesisTx, 1, &types.CellOutput{
+		Lock: &types.Script{CodeHash: types.Hash{}, HashType: types.HashTypeData, Args: []byte{}},
+		Type: &types.Script{
+			CodeHash: types.HexToHash(typeIDCodeHash),
+			HashType: types.HashTypeType,
+			Args:     common.FromHex("0x8536c9d5d908bd89fc70099e4284870708b6632356aad98734fcf43f6f71c304"),
+		},
+	}, []byte("secp256k1_blake160_sighash_all"))
+	c.addCell(mainnetGenesisTx, 3, &types.CellOutput{
+		Lock: &types.Script{CodeHash: types.Hash{}, HashType: types.HashTypeData, Args: []byte{}},
+	}, []byte("secp256k1_data"))
+	group := append(molecule.Uint32(2), molecule.SerializeOutPoint(&types.OutPoint{TxHash: types.HexToHash(mainnetGenesisTx), Index: 1})...)
+	group = append(group, molecule.SerializeOutPoint(&types.OutPoint{TxHash: types.HexToHash(mainnetGenesisTx), Index: 3})...)
+	c.addCell(mainnetDepGroupTx, 0, &types.CellOutput{
+		Lock: &types.Script{CodeHash: types.Hash{}, HashType: types.HashTypeData, Args: []byte{}},
+	}, group)
+	for i, lock := range locks {
+		c.addCell("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541", uint(i), &types.CellOutput{Lock: lock}, []byte{})
+	}
+	return c
+}
+
+func secpLock() *types.Script {
+	return &types.Script{
+		CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
+		HashType: types.HashTypeType,
+		Args:     common.FromHex("0xedcda9513fa030ce4308e29245a22c022d0443bb"),
+	}
+}
+
+func spending(count int) *types.Transaction {
+	tx := &types.Transaction{}
+	for i := 0; i < count; i++ {
+		tx.Inputs = append(tx.Inputs, &types.CellInput{PreviousOutput: &types.OutPoint{
+			TxHash: types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541"),
+			Index:  uint(i),
+		}})
+	}
+	return tx
+}
+
+func TestResolveRegistry(t *testing.T) {
+	r := NewResolver(mainnetClient(secpLock(), secpLock()), systemscript.Mainnet)
+	tx := spending(2)
+	if err := r.Resolve(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.CellDeps) != 1 || tx.CellDeps[0] != systemscript.Mainnet.MustGet(systemscript.Secp256k1Blake160).CellDep {
+		t.Fatalf("got %d cell deps, want the registry secp256k1-blake160 dep group", len(tx.CellDeps))
+	}
+}
+
+func TestResolveExistingCodeDep(t *testing.T) {
+	// a code dep of the script cell the registry dep group lists already loads the code.
+	r := NewResolver(mainnetClient(secpLock()), systemscript.Mainnet)
+	code := &types.CellDep{OutPoint: &types.OutPoint{TxHash: types.HexToHash(mainnetGenesisTx), Index: 1}, DepType: types.DepTypeCode}
+	tx := spending(1)
+	tx.CellDeps = []*types.CellDep{code}
+	if err := r.Resolve(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.CellDeps) != 1 || tx.CellDeps[0] != code {
+		t.Errorf("got %d cell deps, want the existing code dep only", len(tx.CellDeps))
+	}
+}
+
+func TestResolveScan(t *testing.T) {
+	code := []byte("data script")
+	dataHash, err := molecule.Hash(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeID := &types.Script{
+		CodeHash: types.HexToHash(typeIDCodeHash),
+		HashType: types.HashTypeType,
+		Args:     common.FromHex("0x1111111111111111111111111111111111111111111111111111111111111111"),
+	}
+	typeHash, err := molecule.ScriptHash(typeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataLock := &types.Script{CodeHash: dataHash, HashType: types.HashTypeData, Args: []byte{}}
+	typeLock := &types.Script{CodeHash: typeHash, HashType: types.HashTypeType, Args: []byte{}}
+
+	c := mainnetClient(typeLock, dataLock)
+	owner := secpLock()
+	deploy := &types.Transaction{
+		Outputs:     []*types.CellOutput{{Lock: owner}, {Lock: owner, Type: typeID}, {Lock: owner}},
+		OutputsData: [][]byte{[]byte("other"), []byte("type script"), code},
+	}
+	c.transactions[types.HexToHash(deployTx)] = deploy
+	for i, output := range deploy.Outputs {
+		c.addCell(deployTx, uint(i), output, deploy.OutputsData[i])
+	}
+
+	r := NewResolver(c, systemscript.Mainnet)
+	r.Transactions = []types.Hash{types.HexToHash(deployTx)}
+	tx := spending(2)
+	if err := r.Resolve(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.CellDeps) != 2 {
+		t.Fatalf("got %d cell deps, want 2", len(tx.CellDeps))
+	}
+	for i, index := range []uint{1, 2} {
+		dep := tx.CellDeps[i]
+		if dep.DepType != types.DepTypeCode || dep.OutPoint.TxHash.String() != deployTx || dep.OutPoint.Index != index {
+			t.Errorf("cell dep %d is %s#%d, want %s#%d", i, dep.OutPoint.TxHash.String(), dep.OutPoint.Index, deployTx, index)
+		}
+	}
+	if err := r.Verify(context.Background(), tx.CellDeps[0], dataLock); err == nil {
+		t.Error("verified the type script cell as the code of the data script")
+	}
+
+	r.Transactions = nil
+	unknown := &types.Script{CodeHash: types.HexToHash(typeIDCodeHash), HashType: types.HashTypeData, Args: []byte{}}
+	if _, err := r.CellDep(context.Background(), unknown); !errors.Is(err, ErrNotFound) {
+		t.Errorf("cell dep of an unknown script returned %v, want ErrNotFound", err)
+	}
+}
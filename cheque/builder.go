@@ -0,0 +1,212 @@
+package cheque
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/builder"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+	"github.com/shaojunda/ckb-rich-sdk-go/udt"
+)
+
+// Builder builds unsigned cheque transactions of secp256k1-blake160 addresses. Cheque inputs are unlocked by an
+// input of the receiver or sender lock in the same transaction, their witnesses stay empty.
+type Builder struct {
+	client      rpc.Client
+	config      *Config
+	tokenConfig *udt.Config
+	// FeeRate is the fee rate in shannons per KB.
+	FeeRate uint64
+}
+
+func NewBuilder(client rpc.Client, config *Config, tokenConfig *udt.Config) *Builder {
+	return &Builder{
+		client:      client,
+		config:      config,
+		tokenConfig: tokenConfig,
+		FeeRate:     builder.DefaultFeeRate,
+	}
+}
+
+// Send locks amount of the token of the sender address in a cheque cell the receiver address can claim. The sender
+// pays the cheque cell capacity and gets it back when the cheque is claimed or withdrawn.
+func (b *Builder) Send(ctx context.Context, from string, to string, token *types.Script, amount *big.Int) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	sender, err := address.Parse(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	receiver, err := address.Parse(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	lock, err := b.config.Script(sender.Script, receiver.Script)
+	if err != nil {
+		return nil, nil, err
+	}
+	cells, err := udt.Cells(ctx, b.client, sender.Script, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs, change, err := udt.SelectAmount(cells, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := builder.NewTransferBuilder(b.client)
+	t.FeeRate = b.FeeRate
+	t.AddCellDep(b.tokenConfig.CellDep)
+	err = t.AddSender(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, input := range inputs {
+		t.AddInput(input)
+	}
+	err = addTokenOutput(t, lock, token, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if change.Sign() > 0 {
+		err = addTokenOutput(t, sender.Script, token, change)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return t.Build(ctx)
+}
+
+// Claim claims the cheques of a token sent to the receiver address into a new token cell. The receiver pays the
+// token cell capacity and the fee, the capacity of every cheque returns to its sender.
+func (b *Builder) Claim(ctx context.Context, receiver string, cheques []*Cheque) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	token, amount, err := total(cheques)
+	if err != nil {
+		return nil, nil, err
+	}
+	parsed, err := address.Parse(receiver)
+	if err != nil {
+		return nil, nil, err
+	}
+	receiverHash, err := lockHash(parsed.Script)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := b.transferBuilder()
+	err = t.AddSender(receiver)
+	if err != nil {
+		return nil, nil, err
+	}
+	returned := make(map[string]*types.CellOutput)
+	var senders []string
+	for _, cheque := range cheques {
+		if !bytes.Equal(cheque.ReceiverLockHash, receiverHash) {
+			return nil, nil, fmt.Errorf("cheque %s#%d is not sent to %s", cheque.OutPoint.TxHash.String(), cheque.OutPoint.Index, receiver)
+		}
+		t.AddInput(cheque.LiveCell)
+		t.AddUnsignedLock(cheque.Output.Lock)
+		key := string(cheque.SenderLockHash)
+		if output, ok := returned[key]; ok {
+			output.Capacity += cheque.Output.Capacity
+			continue
+		}
+		lock, err := SenderLock(ctx, b.client, cheque)
+		if err != nil {
+			return nil, nil, err
+		}
+		returned[key] = &types.CellOutput{
+			Capacity: cheque.Output.Capacity,
+			Lock:     lock,
+		}
+		senders = append(senders, key)
+	}
+	err = addTokenOutput(t, parsed.Script, token, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range senders {
+		t.AddOutput(returned[key], []byte{})
+	}
+	return t.Build(ctx)
+}
+
+// Withdraw takes back the unclaimed cheques of a token sent by the sender address into a new token cell, no earlier
+// than WithdrawSince after the cheques were sent.
+func (b *Builder) Withdraw(ctx context.Context, sender string, cheques []*Cheque) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	token, amount, err := total(cheques)
+	if err != nil {
+		return nil, nil, err
+	}
+	parsed, err := address.Parse(sender)
+	if err != nil {
+		return nil, nil, err
+	}
+	senderHash, err := lockHash(parsed.Script)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := b.transferBuilder()
+	err = t.AddSender(sender)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, cheque := range cheques {
+		if !bytes.Equal(cheque.SenderLockHash, senderHash) {
+			return nil, nil, fmt.Errorf("cheque %s#%d is not sent by %s", cheque.OutPoint.TxHash.String(), cheque.OutPoint.Index, sender)
+		}
+		t.AddInputWithSince(cheque.LiveCell, WithdrawSince)
+		t.AddUnsignedLock(cheque.Output.Lock)
+	}
+	err = addTokenOutput(t, parsed.Script, token, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t.Build(ctx)
+}
+
+// transferBuilder returns a transfer builder which always spends a cell of the sender, the input which unlocks the
+// cheques. The cheque inputs are added as unsigned locks, their witnesses stay empty.
+func (b *Builder) transferBuilder() *builder.TransferBuilder {
+	t := builder.NewTransferBuilder(b.client)
+	t.FeeRate = b.FeeRate
+	t.RequireSenderInput = true
+	t.AddCellDep(b.config.CellDep)
+	t.AddCellDep(b.tokenConfig.CellDep)
+	return t
+}
+
+func addTokenOutput(t *builder.TransferBuilder, lock *types.Script, token *types.Script, amount *big.Int) error {
+	data, err := udt.EncodeAmount(amount)
+	if err != nil {
+		return err
+	}
+	t.AddOutput(&types.CellOutput{
+		Capacity: udt.OccupiedCapacity(lock, token),
+		Lock:     lock,
+		Type:     token,
+	}, data)
+	return nil
+}
+
+// total returns the token of the cheques and their total amount.
+func total(cheques []*Cheque) (*types.Script, *big.Int, error) {
+	if len(cheques) == 0 {
+		return nil, nil, errors.New("no cheque")
+	}
+	token := cheques[0].Token()
+	amount := new(big.Int)
+	for _, cheque := range cheques {
+		other := cheque.Token()
+		if other.CodeHash != token.CodeHash || other.HashType != token.HashType || !bytes.Equal(other.Args, token.Args) {
+			return nil, nil, errors.New("cheques hold different tokens")
+		}
+		amount.Add(amount, cheque.Amount)
+	}
+	return token, amount, nil
+}
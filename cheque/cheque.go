@@ -0,0 +1,206 @@
+// Package cheque sends tokens to receivers without an anyone-can-pay cell, who claim them later, and lets senders
+// withdraw unclaimed cheques.
+package cheque
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/collector"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/since"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+	"github.com/shaojunda/ckb-rich-sdk-go/udt"
+)
+
+const (
+	// WithdrawSince is the relative since of 6 epochs after which the sender may withdraw an unclaimed cheque.
+	WithdrawSince = since.FlagRelative | since.FlagEpoch | 6
+
+	lockHashLength = 20
+)
+
+// Config is a deployment of the cheque lock.
+type Config struct {
+	CodeHash types.Hash
+	HashType types.ScriptHashType
+	CellDep  *types.CellDep
+}
+
+var (
+	MainnetCheque = configOf(systemscript.Mainnet.MustGet(systemscript.Cheque))
+	TestnetCheque = configOf(systemscript.Testnet.MustGet(systemscript.Cheque))
+)
+
+// NewConfig returns the cheque deployment of the registry, such as a devnet registry it is registered in.
+func NewConfig(registry *systemscript.Registry) (*Config, error) {
+	script, err := registry.Get(systemscript.Cheque)
+	if err != nil {
+		return nil, err
+	}
+	return configOf(script), nil
+}
+
+func configOf(script *systemscript.Script) *Config {
+	return &Config{
+		CodeHash: script.CodeHash,
+		HashType: script.HashType,
+		CellDep:  script.CellDep,
+	}
+}
+
+// ChequeConfig returns the cheque deployment of the network.
+func ChequeConfig(mode address.Mode) (*Config, error) {
+	registry, err := systemscript.ForNetwork(mode)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfig(registry)
+}
+
+// Script returns the cheque lock from the sender to the receiver: the first 20 bytes of the receiver lock hash
+// followed by the first 20 bytes of the sender lock hash.
+func (c *Config) Script(sender *types.Script, receiver *types.Script) (*types.Script, error) {
+	senderHash, err := lockHash(sender)
+	if err != nil {
+		return nil, err
+	}
+	receiverHash, err := lockHash(receiver)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Script{
+		CodeHash: c.CodeHash,
+		HashType: c.HashType,
+		Args:     append(receiverHash, senderHash...),
+	}, nil
+}
+
+// Matches reports whether the lock is a cheque lock of the deployment.
+func (c *Config) Matches(lock *types.Script) bool {
+	return lock != nil && lock.CodeHash == c.CodeHash && lock.HashType == c.HashType && len(lock.Args) == 2*lockHashLength
+}
+
+// Cheque is a pending cheque cell.
+type Cheque struct {
+	*indexer.LiveCell
+	// ReceiverLockHash and SenderLockHash are the first 20 bytes of the receiver and sender lock hashes.
+	ReceiverLockHash []byte
+	SenderLockHash   []byte
+	Amount           *big.Int
+}
+
+// Token returns the type script of the token of the cheque.
+func (c *Cheque) Token() *types.Script {
+	return c.Output.Type
+}
+
+// ListReceived returns the pending cheques sent to the receiver lock, searching by the receiver lock hash prefix of the args.
+func (c *Config) ListReceived(ctx context.Context, source collector.CellSource, receiver *types.Script) ([]*Cheque, error) {
+	receiverHash, err := lockHash(receiver)
+	if err != nil {
+		return nil, err
+	}
+	return c.list(ctx, source, receiverHash, func(args []byte) bool {
+		return true
+	})
+}
+
+// ListSent returns the pending cheques sent from the sender lock. The sender lock hash is the suffix of the args, so
+// every cheque cell is scanned unless receiver narrows the search to the cheques sent to one receiver lock.
+func (c *Config) ListSent(ctx context.Context, source collector.CellSource, sender *types.Script, receiver *types.Script) ([]*Cheque, error) {
+	senderHash, err := lockHash(sender)
+	if err != nil {
+		return nil, err
+	}
+	var prefix []byte
+	if receiver != nil {
+		prefix, err = lockHash(receiver)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.list(ctx, source, prefix, func(args []byte) bool {
+		return bytes.Equal(args[lockHashLength:], senderHash)
+	})
+}
+
+func (c *Config) list(ctx context.Context, source collector.CellSource, prefix []byte, match func(args []byte) bool) ([]*Cheque, error) {
+	cc := collector.NewCellCollector(source, &indexer.SearchKey{
+		Script: &types.Script{
+			CodeHash: c.CodeHash,
+			HashType: c.HashType,
+			Args:     prefix,
+		},
+		ScriptType: indexer.ScriptTypeLock,
+		ArgsLen:    2 * lockHashLength,
+	}, nil)
+	cc.AllowTypeScript = true
+	cc.AllowData = true
+	cc.Skip = func(cell *indexer.LiveCell) bool {
+		return !c.Matches(cell.Output.Lock) || cell.Output.Type == nil || len(cell.OutputData) < udt.AmountLength || !match(cell.Output.Lock.Args)
+	}
+	cells, err := cc.Candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cheques := make([]*Cheque, len(cells))
+	for i, cell := range cells {
+		amount, err := udt.ParseAmount(cell.OutputData)
+		if err != nil {
+			return nil, err
+		}
+		cheques[i] = &Cheque{
+			LiveCell:         cell,
+			ReceiverLockHash: cell.Output.Lock.Args[:lockHashLength],
+			SenderLockHash:   cell.Output.Lock.Args[lockHashLength:],
+			Amount:           amount,
+		}
+	}
+	return cheques, nil
+}
+
+// SenderLock returns the lock of the cheque sender, found among the outputs and spent inputs of the transaction
+// which created the cheque.
+func SenderLock(ctx context.Context, client rpc.Client, cheque *Cheque) (*types.Script, error) {
+	tx, err := client.GetTransaction(ctx, cheque.OutPoint.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, output := range tx.Transaction.Outputs {
+		if matchesHash(output.Lock, cheque.SenderLockHash) {
+			return output.Lock, nil
+		}
+	}
+	for _, input := range tx.Transaction.Inputs {
+		previous, err := client.GetTransaction(ctx, input.PreviousOutput.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		lock := previous.Transaction.Outputs[input.PreviousOutput.Index].Lock
+		if matchesHash(lock, cheque.SenderLockHash) {
+			return lock, nil
+		}
+	}
+	return nil, fmt.Errorf("sender lock of cheque %s#%d not found", cheque.OutPoint.TxHash.String(), cheque.OutPoint.Index)
+}
+
+func matchesHash(lock *types.Script, hash []byte) bool {
+	lockHash, err := lockHash(lock)
+	return err == nil && bytes.Equal(lockHash, hash)
+}
+
+func lockHash(lock *types.Script) ([]byte, error) {
+	hash, err := molecule.ScriptHash(lock)
+	if err != nil {
+		return nil, err
+	}
+	return hash.Bytes()[:lockHashLength], nil
+}
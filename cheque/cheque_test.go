@@ -0,0 +1,220 @@
+package cheque
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/builder"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+	"github.com/shaojunda/ckb-rich-sdk-go/udt"
+)
+
+const (
+	senderAddress   = "ckb1qyqwmndf2yl6qvxwgvyw9yj95gkqytgygwasshh9m8"
+	receiverAddress = "ckb1qyqvsv5240xeh85wvnau2eky8pwrhh4jr8ts6f6daz"
+	chequeTx        = "0x4bb9b6b2d37ac4fe8fd2be4ac3bf12e2a4d52baf9f0dcfce9da6bc4a7e6ab3b6"
+)
+
+// chainClient serves the live cells whose lock args start with the search key args, the transaction which sent the
+// cheques and the mainnet genesis system cells, leaving the other methods unimplemented.
+type chainClient struct {
+	rpc.Client
+	cells []*indexer.LiveCell
+	sent  *types.Transaction
+}
+
+func (c *chainClient) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	var cells []*indexer.LiveCell
+	for _, cell := range c.cells {
+		if cell.Output.Lock.CodeHash == searchKey.Script.CodeHash && bytes.HasPrefix(cell.Output.Lock.Args, searchKey.Script.Args) {
+			cells = append(cells, cell)
+		}
+	}
+	return &indexer.LiveCells{Objects: cells}, nil
+}
+
+func (c *chainClient) GetTransaction(ctx context.Context, hash types.Hash) (*types.TransactionWithStatus, error) {
+	return &types.TransactionWithStatus{Transaction: c.sent}, nil
+}
+
+func (c *chainClient) GetBlockByNumber(ctx context.Context, number uint64) (*types.Block, error) {
+	typeID := func(args string) *types.Script {
+		return &types.Script{
+			CodeHash: types.HexToHash("0x00000000000000000000000000000000000000000000000000545950455f4944"),
+			HashType: types.HashTypeType,
+			Args:     common.FromHex(args),
+		}
+	}
+	return &types.Block{Transactions: []*types.Transaction{
+		{
+			Hash: types.HexToHash("0xe2fb199810d49a4d8beec56718ba2593b665db9d52299a0f9e6e75416d73ff5c"),
+			Outputs: []*types.CellOutput{
+				{},
+				{Type: typeID("0x8536c9d5d908bd89fc70099e4284870708b6632356aad98734fcf43f6f71c304")},
+				{Type: typeID("0xb2a8500929d6a1294bf9bf1bf565f549fa4a5f1316a3306ad3d4783e64bcf626")},
+				{},
+				{Type: typeID("0xd813c1b15bd79c8321ad7f5819e5d9f659a1042b72e64659a2c092be68ea9758")},
+			},
+		},
+		{
+			Hash:    types.HexToHash("0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c"),
+			Outputs: []*types.CellOutput{{}, {}},
+		},
+	}}, nil
+}
+
+func parse(t *testing.T, addr string) *types.Script {
+	parsed, err := address.Parse(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Script
+}
+
+func liveCell(txHash string, index uint, output *types.CellOutput, data []byte) *indexer.LiveCell {
+	return &indexer.LiveCell{
+		OutPoint:   &types.OutPoint{TxHash: types.HexToHash(txHash), Index: index},
+		Output:     output,
+		OutputData: data,
+	}
+}
+
+// testChain returns the token and a client serving two cheques of 10 and 20 tokens from the sender to the receiver,
+// and a 1000 CKB cell of each of them.
+func testChain(t *testing.T) (*types.Script, *chainClient) {
+	sender, receiver := parse(t, senderAddress), parse(t, receiverAddress)
+	token := &types.Script{
+		CodeHash: udt.MainnetSUDT.CodeHash,
+		HashType: udt.MainnetSUDT.HashType,
+		Args:     common.FromHex("0x9ec9ae72e4579980e41554100f1219ff97599f8ab7e79f074b72ed8b9dc1d3b1"),
+	}
+	lock, err := MainnetCheque.Script(sender, receiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &chainClient{sent: &types.Transaction{Outputs: []*types.CellOutput{{Lock: lock}, {Lock: sender}}}}
+	for i, amount := range []int64{10, 20} {
+		data, err := udt.EncodeAmount(big.NewInt(amount))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.cells = append(c.cells, liveCell(chequeTx, uint(i), &types.CellOutput{Capacity: 162 * 100000000, Lock: lock, Type: token}, data))
+	}
+	c.cells = append(c.cells,
+		liveCell("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541", 0, &types.CellOutput{Capacity: 1000 * 100000000, Lock: sender}, []byte{}),
+		liveCell("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541", 1, &types.CellOutput{Capacity: 1000 * 100000000, Lock: receiver}, []byte{}))
+	return token, c
+}
+
+func TestScript(t *testing.T) {
+	sender, receiver := parse(t, senderAddress), parse(t, receiverAddress)
+	lock, err := MainnetCheque.Script(sender, receiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	senderHash, _ := lockHash(sender)
+	receiverHash, _ := lockHash(receiver)
+	if !bytes.Equal(lock.Args, append(receiverHash, senderHash...)) || !MainnetCheque.Matches(lock) {
+		t.Errorf("cheque lock args are %x", lock.Args)
+	}
+}
+
+func TestList(t *testing.T) {
+	_, client := testChain(t)
+	sender, receiver := parse(t, senderAddress), parse(t, receiverAddress)
+	received, err := MainnetCheque.ListReceived(context.Background(), client, receiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 || received[0].Amount.Int64() != 10 || received[1].Amount.Int64() != 20 {
+		t.Fatalf("got %d received cheques", len(received))
+	}
+	sent, err := MainnetCheque.ListSent(context.Background(), client, sender, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 {
+		t.Errorf("got %d sent cheques", len(sent))
+	}
+	sent, err = MainnetCheque.ListSent(context.Background(), client, receiver, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 0 {
+		t.Errorf("got %d cheques sent by the receiver", len(sent))
+	}
+}
+
+func TestClaimAndWithdraw(t *testing.T) {
+	token, client := testChain(t)
+	cheques, err := MainnetCheque.ListReceived(context.Background(), client, parse(t, receiverAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBuilder(client, MainnetCheque, udt.MainnetSUDT)
+
+	tx, groups, err := b.Claim(context.Background(), receiverAddress, cheques)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUnsignedCheques(t, "claim", tx, groups, 2)
+	amount, err := udt.ParseAmount(tx.OutputsData[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount.Int64() != 30 || !bytes.Equal(tx.Outputs[0].Type.Args, token.Args) {
+		t.Errorf("claimed %s tokens", amount.String())
+	}
+	// the capacity of both cheques returns to the sender in one output.
+	if tx.Outputs[1].Capacity != 2*162*100000000 || !bytes.Equal(tx.Outputs[1].Lock.Args, parse(t, senderAddress).Args) {
+		t.Errorf("returned %d shannons to %x", tx.Outputs[1].Capacity, tx.Outputs[1].Lock.Args)
+	}
+
+	tx, groups, err = b.Withdraw(context.Background(), senderAddress, cheques)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUnsignedCheques(t, "withdraw", tx, groups, 2)
+	if tx.Inputs[0].Since != WithdrawSince || tx.Inputs[1].Since != WithdrawSince {
+		t.Errorf("withdraw inputs have since %#x and %#x", tx.Inputs[0].Since, tx.Inputs[1].Since)
+	}
+
+	if _, _, err := b.Withdraw(context.Background(), receiverAddress, cheques); err == nil {
+		t.Error("withdrew the cheques of another sender")
+	}
+	if _, _, err := b.Claim(context.Background(), senderAddress, cheques); err == nil {
+		t.Error("claimed the cheques of another receiver")
+	}
+}
+
+// checkUnsignedCheques checks that the cheque inputs, the first count inputs, have empty witnesses and no group,
+// and that the fee is the minimum fee of the transaction.
+func checkUnsignedCheques(t *testing.T, name string, tx *types.Transaction, groups []*transaction.ScriptGroup, count int) {
+	for i := 0; i < count; i++ {
+		if len(tx.Witnesses[i]) != 0 {
+			t.Errorf("%s: cheque input %d has witness %x", name, i, tx.Witnesses[i])
+		}
+	}
+	if len(groups) != 1 || groups[0].InputIndices[0] != count {
+		t.Errorf("%s: got %d groups, want the group of the sender cell", name, len(groups))
+	}
+	inputs := uint64(count)*162*100000000 + 1000*100000000
+	var outputs uint64
+	for _, output := range tx.Outputs {
+		outputs += output.Capacity
+	}
+	minimum, err := transaction.MinimumFee(tx, builder.DefaultFeeRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inputs-outputs != minimum {
+		t.Errorf("%s: fee is %d, want the minimum fee %d", name, inputs-outputs, minimum)
+	}
+}
@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+)
+
+// DefaultPageSize is the number of cells fetched per indexer call.
+const DefaultPageSize = 100
+
+var ErrInsufficientCapacity = errors.New("insufficient capacity")
+
+// CellSource pages live cells by search key, implemented by indexer.Client, rpc.Client and the cell cache.
+type CellSource interface {
+	GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error)
+}
+
+type CollectResult struct {
+	Cells    []*indexer.LiveCell
+	Capacity uint64
+}
+
+// CellCollector selects live cells of a search key covering a target capacity.
+type CellCollector struct {
+	Source    CellSource
+	SearchKey *indexer.SearchKey
+	Strategy  Strategy
+	// AllowTypeScript collects cells with a type script, which are skipped by default.
+	AllowTypeScript bool
+	// AllowData collects cells with output data, which are skipped by default.
+	AllowData bool
+	PageSize  uint64
+	// MaxCells caps the number of candidate cells fetched from the source, zero for no cap.
+	MaxCells int
+	// Skip excludes cells from the candidates, e.g. cells already used by another transaction.
+	Skip func(cell *indexer.LiveCell) bool
+}
+
+func NewCellCollector(source CellSource, searchKey *indexer.SearchKey, strategy Strategy) *CellCollector {
+	return &CellCollector{
+		Source:    source,
+		SearchKey: searchKey,
+		Strategy:  strategy,
+		PageSize:  DefaultPageSize,
+	}
+}
+
+// Collect selects cells whose capacity covers capacity plus fee.
+func (c *CellCollector) Collect(ctx context.Context, capacity uint64, fee uint64) (*CollectResult, error) {
+	target := capacity + fee
+	candidates, err := c.Candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cells, err := c.Strategy.Select(candidates, target)
+	if err != nil {
+		return nil, err
+	}
+	result := &CollectResult{
+		Cells: cells,
+	}
+	for _, cell := range cells {
+		result.Capacity += cell.Output.Capacity
+	}
+	if result.Capacity < target {
+		return nil, ErrInsufficientCapacity
+	}
+	return result, nil
+}
+
+// Candidates returns all cells of the search key the collector may select.
+func (c *CellCollector) Candidates(ctx context.Context) ([]*indexer.LiveCell, error) {
+	pageSize := c.PageSize
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+
+	var candidates []*indexer.LiveCell
+	cursor := ""
+	for {
+		cells, err := c.Source.GetCells(ctx, c.SearchKey, indexer.SearchOrderAsc, pageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for _, cell := range cells.Objects {
+			if !c.allow(cell) {
+				continue
+			}
+			candidates = append(candidates, cell)
+			if c.MaxCells > 0 && len(candidates) >= c.MaxCells {
+				return candidates, nil
+			}
+		}
+		if uint64(len(cells.Objects)) < pageSize {
+			return candidates, nil
+		}
+		cursor = cells.LastCursor
+	}
+}
+
+func (c *CellCollector) allow(cell *indexer.LiveCell) bool {
+	if cell.Output.Type != nil && !c.AllowTypeScript {
+		return false
+	}
+	if len(cell.OutputData) > 0 && !c.AllowData {
+		return false
+	}
+	if c.Skip != nil && c.Skip(cell) {
+		return false
+	}
+	return true
+}
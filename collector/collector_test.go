@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+)
+
+// pagedSource pages the cells by limit, its cursor is the index of the next cell.
+type pagedSource struct {
+	cells []*indexer.LiveCell
+	calls int
+}
+
+func (s *pagedSource) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	s.calls++
+	start := 0
+	if afterCursor != "" {
+		var err error
+		start, err = strconv.Atoi(afterCursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+	end := start + int(limit)
+	if end > len(s.cells) {
+		end = len(s.cells)
+	}
+	return &indexer.LiveCells{Objects: s.cells[start:end], LastCursor: strconv.Itoa(end)}, nil
+}
+
+func cells(capacities ...uint64) []*indexer.LiveCell {
+	result := make([]*indexer.LiveCell, len(capacities))
+	for i, capacity := range capacities {
+		result[i] = &indexer.LiveCell{
+			OutPoint: &types.OutPoint{Index: uint(i)},
+			Output:   &types.CellOutput{Capacity: capacity},
+		}
+	}
+	return result
+}
+
+func capacities(cells []*indexer.LiveCell) []uint64 {
+	result := make([]uint64, len(cells))
+	for i, cell := range cells {
+		result[i] = cell.Output.Capacity
+	}
+	return result
+}
+
+func equal(a []uint64, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStrategies(t *testing.T) {
+	candidates := cells(50, 10, 30, 20, 5)
+	tests := []struct {
+		name     string
+		strategy Strategy
+		target   uint64
+		want     []uint64
+	}{
+		{"largest first", LargestFirst{}, 35, []uint64{50}},
+		{"smallest first", SmallestFirst{}, 35, []uint64{5, 10, 20}},
+		{"minimize change single cell", MinimizeChange{}, 35, []uint64{50}},
+		{"minimize change greedy", MinimizeChange{}, 55, []uint64{50, 30}},
+		{"branch and bound exact", BranchAndBound{}, 35, []uint64{30, 5}},
+		{"branch and bound within change cost", BranchAndBound{CostOfChange: 4}, 36, []uint64{30, 10}},
+		{"branch and bound fallback", BranchAndBound{}, 36, []uint64{50}},
+		{"branch and bound custom fallback", BranchAndBound{Fallback: SmallestFirst{}}, 36, []uint64{5, 10, 20, 30}},
+	}
+	for _, tt := range tests {
+		selected, err := tt.strategy.Select(candidates, tt.target)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := capacities(selected); !equal(got, tt.want) {
+			t.Errorf("%s: selected %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := capacities(candidates); !equal(got, []uint64{50, 10, 30, 20, 5}) {
+		t.Errorf("strategies reordered the candidates to %v", got)
+	}
+
+	for _, strategy := range []Strategy{LargestFirst{}, SmallestFirst{}, MinimizeChange{}, BranchAndBound{}} {
+		if _, err := strategy.Select(candidates, 116); !errors.Is(err, ErrInsufficientCapacity) {
+			t.Errorf("%T selected above the total capacity with %v", strategy, err)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	all := cells(1, 2, 3, 4, 5, 6, 7)
+	all[1].Output.Type = &types.Script{}
+	all[2].OutputData = []byte{1}
+	source := &pagedSource{cells: all}
+	c := NewCellCollector(source, &indexer.SearchKey{}, LargestFirst{})
+	c.PageSize = 3
+	c.Skip = func(cell *indexer.LiveCell) bool {
+		return cell.Output.Capacity == 4
+	}
+	candidates, err := c.Candidates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := capacities(candidates); !equal(got, []uint64{1, 5, 6, 7}) || source.calls != 3 {
+		t.Errorf("got candidates %v in %d pages", got, source.calls)
+	}
+
+	c.AllowTypeScript, c.AllowData, c.Skip = true, true, nil
+	c.MaxCells = 4
+	source.calls = 0
+	candidates, err = c.Candidates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := capacities(candidates); !equal(got, []uint64{1, 2, 3, 4}) || source.calls != 2 {
+		t.Errorf("got candidates %v in %d pages", got, source.calls)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	c := NewCellCollector(&pagedSource{cells: cells(50, 10, 30)}, &indexer.SearchKey{}, SmallestFirst{})
+	result, err := c.Collect(context.Background(), 35, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Capacity != 40 || !equal(capacities(result.Cells), []uint64{10, 30}) {
+		t.Errorf("collected %v of %d shannons", capacities(result.Cells), result.Capacity)
+	}
+	if _, err := c.Collect(context.Background(), 85, 6); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("collected above the total capacity with %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package collector
+
+import (
+	"sort"
+
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+)
+
+// DefaultMaxTries is the number of search steps of BranchAndBound before falling back.
+const DefaultMaxTries = 100000
+
+// Strategy selects the cells to spend among the candidates.
+type Strategy interface {
+	// Select returns cells whose capacity sum is at least target, or ErrInsufficientCapacity.
+	Select(cells []*indexer.LiveCell, target uint64) ([]*indexer.LiveCell, error)
+}
+
+// LargestFirst selects the largest cells first, spending as few cells as possible.
+type LargestFirst struct{}
+
+func (LargestFirst) Select(cells []*indexer.LiveCell, target uint64) ([]*indexer.LiveCell, error) {
+	return accumulate(sortByCapacity(cells, true), target)
+}
+
+// SmallestFirst selects the smallest cells first, consolidating dust cells.
+type SmallestFirst struct{}
+
+func (SmallestFirst) Select(cells []*indexer.LiveCell, target uint64) ([]*indexer.LiveCell, error) {
+	return accumulate(sortByCapacity(cells, false), target)
+}
+
+// MinimizeChange selects the cells leaving the least change among the smallest single cell covering
+// the target and the largest cells first with unneeded cells removed.
+type MinimizeChange struct{}
+
+func (MinimizeChange) Select(cells []*indexer.LiveCell, target uint64) ([]*indexer.LiveCell, error) {
+	ascending := sortByCapacity(cells, false)
+	var single []*indexer.LiveCell
+	i := sort.Search(len(ascending), func(i int) bool {
+		return ascending[i].Output.Capacity >= target
+	})
+	if i < len(ascending) {
+		single = ascending[i : i+1]
+	}
+
+	greedy, err := accumulate(sortByCapacity(cells, true), target)
+	if err != nil {
+		return nil, err
+	}
+	// drop the smallest selected cells while the rest still covers the target
+	total := sum(greedy)
+	for len(greedy) > 1 && total-greedy[len(greedy)-1].Output.Capacity >= target {
+		total -= greedy[len(greedy)-1].Output.Capacity
+		greedy = greedy[:len(greedy)-1]
+	}
+
+	if single != nil && sum(single)-target <= total-target {
+		return single, nil
+	}
+	return greedy, nil
+}
+
+// BranchAndBound searches for cells summing between target and target plus CostOfChange, so that no
+// change output is needed, and uses Fallback when there is no such selection.
+type BranchAndBound struct {
+	// CostOfChange is the capacity a change output would take, including its fee.
+	CostOfChange uint64
+	MaxTries     int
+	// Fallback defaults to MinimizeChange.
+	Fallback Strategy
+}
+
+func (s BranchAndBound) Select(cells []*indexer.LiveCell, target uint64) ([]*indexer.LiveCell, error) {
+	descending := sortByCapacity(cells, true)
+	remaining := make([]uint64, len(descending)+1)
+	for i := len(descending) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + descending[i].Output.Capacity
+	}
+	if remaining[0] < target {
+		return nil, ErrInsufficientCapacity
+	}
+
+	maxTries := s.MaxTries
+	if maxTries <= 0 {
+		maxTries = DefaultMaxTries
+	}
+	upper := target + s.CostOfChange
+	tries := 0
+	var selected, best []int
+	var bestWaste uint64
+
+	var search func(depth int, total uint64) bool
+	search = func(depth int, total uint64) bool {
+		tries++
+		if tries > maxTries {
+			return true
+		}
+		if total > upper || total+remaining[depth] < target {
+			return false
+		}
+		if total >= target {
+			waste := total - target
+			if best == nil || waste < bestWaste {
+				best = append(best[:0], selected...)
+				bestWaste = waste
+			}
+			return waste == 0
+		}
+		if depth == len(descending) {
+			return false
+		}
+		selected = append(selected, depth)
+		if search(depth+1, total+descending[depth].Output.Capacity) {
+			return true
+		}
+		selected = selected[:len(selected)-1]
+		return search(depth+1, total)
+	}
+	search(0, 0)
+
+	if best == nil {
+		fallback := s.Fallback
+		if fallback == nil {
+			fallback = MinimizeChange{}
+		}
+		return fallback.Select(cells, target)
+	}
+	result := make([]*indexer.LiveCell, len(best))
+	for i, index := range best {
+		result[i] = descending[index]
+	}
+	return result, nil
+}
+
+func accumulate(cells []*indexer.LiveCell, target uint64) ([]*indexer.LiveCell, error) {
+	var total uint64
+	for i, cell := range cells {
+		total += cell.Output.Capacity
+		if total >= target {
+			return cells[:i+1], nil
+		}
+	}
+	return nil, ErrInsufficientCapacity
+}
+
+func sortByCapacity(cells []*indexer.LiveCell, descending bool) []*indexer.LiveCell {
+	sorted := make([]*indexer.LiveCell, len(cells))
+	copy(sorted, cells)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if descending {
+			return sorted[i].Output.Capacity > sorted[j].Output.Capacity
+		}
+		return sorted[i].Output.Capacity < sorted[j].Output.Capacity
+	})
+	return sorted
+}
+
+func sum(cells []*indexer.LiveCell) uint64 {
+	var total uint64
+	for _, cell := range cells {
+		total += cell.Output.Capacity
+	}
+	return total
+}
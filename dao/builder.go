@@ -0,0 +1,201 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/builder"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/signer"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+// Builder builds unsigned DAO transactions of secp256k1-blake160 addresses.
+type Builder struct {
+	client rpc.Client
+	// FeeRate is the fee rate in shannons per KB.
+	FeeRate uint64
+	// Registry holds the DAO and secp256k1-blake160 deployments of the chain. When nil, it is read from the genesis
+	// block on first use.
+	Registry *systemscript.Registry
+}
+
+func NewBuilder(client rpc.Client) *Builder {
+	return &Builder{
+		client:  client,
+		FeeRate: builder.DefaultFeeRate,
+	}
+}
+
+// Deposit deposits capacity shannons of the address into a DAO cell locked by the address, the address pays the fee.
+func (b *Builder) Deposit(ctx context.Context, from string, capacity uint64) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	parsed, err := address.Parse(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	script, err := b.script(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	output := &types.CellOutput{
+		Capacity: capacity,
+		Lock:     parsed.Script,
+		Type:     script.Type,
+	}
+	data := make([]byte, dataLength)
+	if occupied := transaction.OccupiedCapacity(output, data); capacity < occupied {
+		return nil, nil, fmt.Errorf("deposit of %d shannons is less than the %d shannons the DAO cell occupies", capacity, occupied)
+	}
+
+	t := b.transferBuilder()
+	err = t.AddSender(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.AddOutput(output, data)
+	t.AddCellDep(script.CellDep)
+	return t.Build(ctx)
+}
+
+// Withdraw starts the withdraw of deposited cells, turning them into withdrawing cells of the same capacity.
+// The address pays the fee.
+func (b *Builder) Withdraw(ctx context.Context, from string, cells []*Cell) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	if len(cells) == 0 {
+		return nil, nil, errors.New("no DAO cell")
+	}
+	script, err := b.script(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := b.transferBuilder()
+	err = t.AddSender(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	headerDeps := make(map[types.Hash]bool)
+	for _, cell := range cells {
+		if cell.Phase != PhaseDeposited {
+			return nil, nil, fmt.Errorf("DAO cell %s#%d is %s", cell.OutPoint.TxHash.String(), cell.OutPoint.Index, cell.Phase)
+		}
+		// the withdrawing cell is the output at the index of its deposit input, holding the deposit block number.
+		t.AddInput(cell.LiveCell)
+		t.AddOutput(&types.CellOutput{
+			Capacity: cell.Output.Capacity,
+			Lock:     cell.Output.Lock,
+			Type:     cell.Output.Type,
+		}, molecule.Uint64(cell.BlockNumber))
+		if !headerDeps[cell.DepositBlockHash] {
+			headerDeps[cell.DepositBlockHash] = true
+			t.AddHeaderDep(cell.DepositBlockHash)
+		}
+	}
+	t.AddCellDep(script.CellDep)
+	return t.Build(ctx)
+}
+
+// Unlock spends withdrawing cells whose since is reached, paying their maximum withdraw less the fee to the address.
+func (b *Builder) Unlock(ctx context.Context, cells []*Cell, to string) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	if len(cells) == 0 {
+		return nil, nil, errors.New("no DAO cell")
+	}
+	parsed, err := address.Parse(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	script, err := b.script(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	secp, err := b.Registry.Get(systemscript.Secp256k1Blake160)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx := &types.Transaction{
+		Version:    0,
+		CellDeps:   []*types.CellDep{secp.CellDep, script.CellDep},
+		HeaderDeps: []types.Hash{},
+	}
+	headerIndices := make(map[types.Hash]int)
+	headerIndex := func(hash types.Hash) int {
+		index, ok := headerIndices[hash]
+		if !ok {
+			index = len(tx.HeaderDeps)
+			headerIndices[hash] = index
+			tx.HeaderDeps = append(tx.HeaderDeps, hash)
+		}
+		return index
+	}
+	var capacity uint64
+	locks := make([]*types.Script, len(cells))
+	for i, cell := range cells {
+		if cell.Phase != PhaseWithdrawing {
+			return nil, nil, fmt.Errorf("DAO cell %s#%d is %s", cell.OutPoint.TxHash.String(), cell.OutPoint.Index, cell.Phase)
+		}
+		tx.Inputs = append(tx.Inputs, &types.CellInput{
+			Since:          cell.Since,
+			PreviousOutput: cell.OutPoint,
+		})
+		// the DAO script reads the deposit header from the header dep the witness input type points to.
+		tx.Witnesses = append(tx.Witnesses, molecule.SerializeWitnessArgs(&types.WitnessArgs{
+			InputType: molecule.Uint64(uint64(headerIndex(cell.DepositBlockHash))),
+		}))
+		headerIndex(cell.WithdrawBlockHash)
+		locks[i] = cell.Output.Lock
+		capacity += cell.MaximumWithdraw
+	}
+
+	groups, err := transaction.GroupInputs(locks)
+	if err != nil {
+		return nil, nil, err
+	}
+	lockSizes := make([]int, len(groups))
+	for i := range groups {
+		lockSizes[i] = signer.SignatureLength
+	}
+	err = transaction.FillPlaceholders(tx, groups, lockSizes)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx.Outputs = []*types.CellOutput{{
+		Capacity: capacity,
+		Lock:     parsed.Script,
+	}}
+	tx.OutputsData = [][]byte{{}}
+
+	fee, err := transaction.MinimumFee(tx, b.FeeRate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if occupied := transaction.OccupiedCapacity(tx.Outputs[0], tx.OutputsData[0]); capacity < fee+occupied {
+		return nil, nil, fmt.Errorf("withdraw of %d shannons does not cover the fee %d and the %d shannons the output occupies", capacity, fee, occupied)
+	}
+	tx.Outputs[0].Capacity = capacity - fee
+	return tx, groups, nil
+}
+
+// script returns the DAO script of the registry, read from the genesis block the first time.
+func (b *Builder) script(ctx context.Context) (*Script, error) {
+	if b.Registry == nil {
+		registry, err := systemscript.FromGenesis(ctx, b.client)
+		if err != nil {
+			return nil, err
+		}
+		b.Registry = registry
+	}
+	return scriptOf(b.Registry)
+}
+
+// transferBuilder returns a transfer builder sharing the registry, called once the registry is known.
+func (b *Builder) transferBuilder() *builder.TransferBuilder {
+	t := builder.NewTransferBuilder(b.client)
+	t.FeeRate = b.FeeRate
+	t.Registry = b.Registry
+	return t
+}
@@ -0,0 +1,159 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+const ownerAddress = "ckb1qyqwmndf2yl6qvxwgvyw9yj95gkqytgygwasshh9m8"
+
+var (
+	depositBlock  = types.HexToHash("0x4bb9b6b2d37ac4fe8fd2be4ac3bf12e2a4d52baf9f0dcfce9da6bc4a7e6ab3b6")
+	withdrawBlock = types.HexToHash("0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c")
+)
+
+// cellsClient serves the live cells of every search key, leaving the other methods unimplemented.
+type cellsClient struct {
+	rpc.Client
+	cells []*indexer.LiveCell
+}
+
+func (c *cellsClient) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	return &indexer.LiveCells{Objects: c.cells}, nil
+}
+
+func testBuilder(t *testing.T) (*Builder, *types.Script) {
+	parsed, err := address.Parse(ownerAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &cellsClient{cells: []*indexer.LiveCell{{
+		OutPoint:   &types.OutPoint{TxHash: types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541")},
+		Output:     &types.CellOutput{Capacity: 1000 * 100000000, Lock: parsed.Script},
+		OutputData: []byte{},
+	}}}
+	b := NewBuilder(client)
+	b.Registry = systemscript.Mainnet
+	return b, parsed.Script
+}
+
+// daoCell returns a DAO cell of 1000 CKB of the lock deposited at block 100.
+func daoCell(t *testing.T, lock *types.Script, index uint, phase Phase) *Cell {
+	script, err := scriptOf(systemscript.Mainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cell := &Cell{
+		LiveCell: &indexer.LiveCell{
+			BlockNumber: 100,
+			OutPoint:    &types.OutPoint{TxHash: types.HexToHash("0xe2fb199810d49a4d8beec56718ba2593b665db9d52299a0f9e6e75416d73ff5c"), Index: index},
+			Output:      &types.CellOutput{Capacity: 1000 * 100000000, Lock: lock, Type: script.Type},
+			OutputData:  make([]byte, dataLength),
+		},
+		Phase:            phase,
+		DepositBlockHash: depositBlock,
+	}
+	if phase == PhaseWithdrawing {
+		cell.WithdrawBlockHash = withdrawBlock
+		cell.MaximumWithdraw = 1000*100000000 + 12345678
+		cell.Since = 0x20000a00b4000006
+	}
+	return cell
+}
+
+func TestDeposit(t *testing.T) {
+	b, lock := testBuilder(t)
+	tx, groups, err := b.Deposit(context.Background(), ownerAddress, 500*100000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposit := tx.Outputs[0]
+	if deposit.Capacity != 500*100000000 || deposit.Type == nil || !bytes.Equal(deposit.Lock.Args, lock.Args) ||
+		!bytes.Equal(tx.OutputsData[0], make([]byte, 8)) {
+		t.Errorf("deposit cell is %+v with data %x", deposit, tx.OutputsData[0])
+	}
+	dao := systemscript.Mainnet.MustGet(systemscript.DAO)
+	if len(tx.CellDeps) != 2 || *tx.CellDeps[1].OutPoint != *dao.CellDep.OutPoint || len(groups) != 1 {
+		t.Errorf("got %d cell deps and %d groups", len(tx.CellDeps), len(groups))
+	}
+
+	if _, _, err := b.Deposit(context.Background(), ownerAddress, 101*100000000); err == nil {
+		t.Error("deposited less than the DAO cell occupies")
+	}
+}
+
+func TestWithdraw(t *testing.T) {
+	b, lock := testBuilder(t)
+	cells := []*Cell{daoCell(t, lock, 1, PhaseDeposited), daoCell(t, lock, 2, PhaseDeposited)}
+	tx, _, err := b.Withdraw(context.Background(), ownerAddress, cells)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// both deposits share the deposit block header.
+	if len(tx.HeaderDeps) != 1 || tx.HeaderDeps[0] != depositBlock {
+		t.Errorf("header deps are %v", tx.HeaderDeps)
+	}
+	for i, cell := range cells {
+		if *tx.Inputs[i].PreviousOutput != *cell.OutPoint {
+			t.Fatalf("input %d is %s#%d", i, tx.Inputs[i].PreviousOutput.TxHash.String(), tx.Inputs[i].PreviousOutput.Index)
+		}
+		if tx.Outputs[i].Capacity != cell.Output.Capacity || tx.Outputs[i].Type != cell.Output.Type || !bytes.Equal(tx.OutputsData[i], molecule.Uint64(100)) {
+			t.Errorf("withdrawing cell %d is %+v with data %x", i, tx.Outputs[i], tx.OutputsData[i])
+		}
+	}
+
+	if _, _, err := b.Withdraw(context.Background(), ownerAddress, []*Cell{daoCell(t, lock, 1, PhaseWithdrawing)}); err == nil {
+		t.Error("withdrew a withdrawing cell")
+	}
+	if _, _, err := b.Withdraw(context.Background(), ownerAddress, nil); err == nil {
+		t.Error("withdrew no cell")
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	b, lock := testBuilder(t)
+	cells := []*Cell{daoCell(t, lock, 1, PhaseWithdrawing), daoCell(t, lock, 2, PhaseWithdrawing)}
+	tx, groups, err := b.Unlock(context.Background(), cells, ownerAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.HeaderDeps) != 2 || tx.HeaderDeps[0] != depositBlock || tx.HeaderDeps[1] != withdrawBlock {
+		t.Errorf("header deps are %v", tx.HeaderDeps)
+	}
+	for i, input := range tx.Inputs {
+		if input.Since != cells[i].Since {
+			t.Errorf("input %d since is %#x", i, input.Since)
+		}
+	}
+	// the input type of each witness is the index of the deposit header dep, the first witness also holds the lock.
+	witness, err := molecule.DeserializeWitnessArgs(tx.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(witness.InputType, molecule.Uint64(0)) || len(witness.Lock) != 65 {
+		t.Errorf("first witness is %x", tx.Witnesses[0])
+	}
+	if len(groups) != 1 || len(groups[0].InputIndices) != 2 {
+		t.Errorf("got %d groups", len(groups))
+	}
+	fee, err := transaction.MinimumFee(tx, b.FeeRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Outputs) != 1 || tx.Outputs[0].Capacity+fee != 2*cells[0].MaximumWithdraw {
+		t.Errorf("unlocked %d shannons with fee %d", tx.Outputs[0].Capacity, fee)
+	}
+
+	if _, _, err := b.Unlock(context.Background(), []*Cell{daoCell(t, lock, 1, PhaseDeposited)}, ownerAddress); err == nil {
+		t.Error("unlocked a deposited cell")
+	}
+}
@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+const (
+	// GenesisAccumulateRate is the accumulate rate of the genesis block, the unit AR is scaled by.
+	GenesisAccumulateRate = 10000000000000000
+
+	millisecondsPerYear = 365 * 24 * 60 * 60 * 1000
+)
+
+// Field is the dao field of a block header.
+type Field struct {
+	// C is the total issuance.
+	C uint64
+	// AR is the accumulate rate, deposits grow by AR divided by the AR of their deposit block.
+	AR uint64
+	// S is the total unissued secondary issuance, the part not paid to DAO depositors.
+	S uint64
+	// U is the total occupied capacity.
+	U uint64
+}
+
+func ParseField(dao types.Hash) *Field {
+	b := dao.Bytes()
+	return &Field{
+		C:  binary.LittleEndian.Uint64(b[0:8]),
+		AR: binary.LittleEndian.Uint64(b[8:16]),
+		S:  binary.LittleEndian.Uint64(b[16:24]),
+		U:  binary.LittleEndian.Uint64(b[24:32]),
+	}
+}
+
+// MaximumWithdraw returns the capacity a DAO cell deposited at depositHeader unlocks when withdrawn at withdrawHeader,
+// the same as the CalculateDaoMaximumWithdraw RPC without the round trip. The occupied capacity of the cell earns no
+// compensation.
+func MaximumWithdraw(output *types.CellOutput, depositHeader *types.Header, withdrawHeader *types.Header) (uint64, error) {
+	deposit := ParseField(depositHeader.Dao)
+	withdraw := ParseField(withdrawHeader.Dao)
+	if deposit.AR == 0 {
+		return 0, errors.New("deposit header has a zero accumulate rate")
+	}
+	occupied := transaction.OccupiedCapacity(output, make([]byte, dataLength))
+	if output.Capacity < occupied {
+		return 0, fmt.Errorf("cell capacity %d is less than its occupied capacity %d", output.Capacity, occupied)
+	}
+
+	counted := new(big.Int).SetUint64(output.Capacity - occupied)
+	counted.Mul(counted, new(big.Int).SetUint64(withdraw.AR))
+	counted.Div(counted, new(big.Int).SetUint64(deposit.AR))
+	counted.Add(counted, new(big.Int).SetUint64(occupied))
+	if !counted.IsUint64() {
+		return 0, errors.New("maximum withdraw overflows")
+	}
+	return counted.Uint64(), nil
+}
+
+// Compensation returns the capacity a DAO cell earns from depositHeader to withdrawHeader.
+func Compensation(output *types.CellOutput, depositHeader *types.Header, withdrawHeader *types.Header) (uint64, error) {
+	maximum, err := MaximumWithdraw(output, depositHeader, withdrawHeader)
+	if err != nil {
+		return 0, err
+	}
+	if maximum < output.Capacity {
+		return 0, nil
+	}
+	return maximum - output.Capacity, nil
+}
+
+// EstimateAPR returns the annual percentage rate deposits earned between the two headers, the growth of the
+// accumulate rate scaled linearly to a year.
+func EstimateAPR(from *types.Header, to *types.Header) (float64, error) {
+	if to.Timestamp <= from.Timestamp {
+		return 0, errors.New("headers must be in ascending timestamp order")
+	}
+	fromAR := ParseField(from.Dao).AR
+	toAR := ParseField(to.Dao).AR
+	if fromAR == 0 {
+		return 0, errors.New("header has a zero accumulate rate")
+	}
+
+	growth := new(big.Float).Quo(new(big.Float).SetUint64(toAR), new(big.Float).SetUint64(fromAR))
+	rate, _ := growth.Float64()
+	return (rate - 1) * millisecondsPerYear / float64(to.Timestamp-from.Timestamp) * 100, nil
+}
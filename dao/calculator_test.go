@@ -0,0 +1,128 @@
+package dao
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// mainnetGenesisDao is the dao field of the mainnet genesis block.
+const mainnetGenesisDao = "0x8874337e541ea12e0000c16ff286230029bfa3320800000000710b00c0fefe06"
+
+func daoHeader(ar uint64, timestamp uint64) *types.Header {
+	dao := make([]byte, 32)
+	binary.LittleEndian.PutUint64(dao[8:16], ar)
+	return &types.Header{
+		Dao:       types.BytesToHash(dao),
+		Timestamp: timestamp,
+	}
+}
+
+// depositCell returns a DAO cell of capacity shannons locked by a secp256k1-blake160 lock, occupying 102 bytes.
+func depositCell(capacity uint64) *types.CellOutput {
+	return &types.CellOutput{
+		Capacity: capacity,
+		Lock: &types.Script{
+			CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
+			HashType: types.HashTypeType,
+			Args:     common.FromHex("0xedcda9513fa030ce4308e29245a22c022d0443bb"),
+		},
+		Type: &types.Script{
+			CodeHash: types.HexToHash("0x82d76d1b75fe2fd9a27dfbaa65a039221a380d76c926f378d3f81cf3e7e13f2e"),
+			HashType: types.HashTypeType,
+			Args:     []byte{},
+		},
+	}
+}
+
+func TestParseField(t *testing.T) {
+	field := ParseField(types.HexToHash(mainnetGenesisDao))
+	want := Field{
+		C:  3360000145238488200,
+		AR: GenesisAccumulateRate,
+		S:  35209330473,
+		U:  504120308900000000,
+	}
+	if *field != want {
+		t.Errorf("genesis dao field is %+v, want %+v", *field, want)
+	}
+}
+
+func TestMaximumWithdraw(t *testing.T) {
+	// the expected capacities follow the formula of the DAO RFC: the capacity above the 102 bytes the cell occupies
+	// grows by the withdraw accumulate rate over the deposit accumulate rate, rounded down.
+	genesis := &types.Header{Dao: types.HexToHash(mainnetGenesisDao)}
+	tests := []struct {
+		name         string
+		deposit      *types.Header
+		withdraw     *types.Header
+		capacity     uint64
+		maximum      uint64
+		compensation uint64
+	}{
+		{"genesis deposit", genesis, daoHeader(10250000000000000, 0), 100000000000, 102245000000, 2245000000},
+		{"rounded down", daoHeader(10000000000123456, 0), daoHeader(10000000001123456, 0), 100000000000, 100000000008, 8},
+		{"same block", genesis, genesis, 100000000000, 100000000000, 0},
+		{"occupied only", genesis, daoHeader(10250000000000000, 0), 10200000000, 10200000000, 0},
+	}
+	for _, tt := range tests {
+		output := depositCell(tt.capacity)
+		maximum, err := MaximumWithdraw(output, tt.deposit, tt.withdraw)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if maximum != tt.maximum {
+			t.Errorf("%s: maximum withdraw is %d, want %d", tt.name, maximum, tt.maximum)
+		}
+		compensation, err := Compensation(output, tt.deposit, tt.withdraw)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if compensation != tt.compensation {
+			t.Errorf("%s: compensation is %d, want %d", tt.name, compensation, tt.compensation)
+		}
+	}
+
+	// the withdraw calculation test of the node DAO calculator, which the RPC calls, unlocks 100000000009999 shannons
+	// of a 1000000 CKB cell occupying 51 bytes: an empty lock, here with 2 bytes of args to make up for the 10 bytes
+	// of cell data there.
+	output := &types.CellOutput{Capacity: 100000000000000, Lock: &types.Script{Args: make([]byte, 2)}}
+	maximum, err := MaximumWithdraw(output, daoHeader(10000000000123456, 0), daoHeader(10000000001123456, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maximum != 100000000009999 {
+		t.Errorf("maximum withdraw is %d, the node calculates 100000000009999", maximum)
+	}
+}
+
+func TestMaximumWithdrawErrors(t *testing.T) {
+	genesis := &types.Header{Dao: types.HexToHash(mainnetGenesisDao)}
+	if _, err := MaximumWithdraw(depositCell(10199999999), genesis, genesis); err == nil {
+		t.Error("maximum withdraw of a cell below its occupied capacity")
+	}
+	if _, err := MaximumWithdraw(depositCell(100000000000), daoHeader(0, 0), genesis); err == nil {
+		t.Error("maximum withdraw of a zero deposit accumulate rate")
+	}
+	if _, err := MaximumWithdraw(depositCell(math.MaxUint64), daoHeader(1, 0), genesis); err == nil {
+		t.Error("maximum withdraw overflowing uint64")
+	}
+}
+
+func TestEstimateAPR(t *testing.T) {
+	const day = 24 * 60 * 60 * 1000
+	// the accumulate rate grows by 0.01% in a day, 3.65% in a year.
+	apr, err := EstimateAPR(daoHeader(GenesisAccumulateRate, 0), daoHeader(GenesisAccumulateRate+GenesisAccumulateRate/10000, day))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(apr-3.65) > 1e-9 {
+		t.Errorf("APR is %f, want 3.65", apr)
+	}
+	if _, err := EstimateAPR(daoHeader(GenesisAccumulateRate, day), daoHeader(GenesisAccumulateRate, day)); err == nil {
+		t.Error("APR of headers with the same timestamp")
+	}
+}
@@ -0,0 +1,194 @@
+// Package dao deposits CKB into the Nervos DAO, withdraws it in two phases and lists the DAO cells of a lock.
+package dao
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/collector"
+	"github.com/shaojunda/ckb-rich-sdk-go/epoch"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/since"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+)
+
+const (
+	// LockEpochs is the deposit period, a withdrawing cell unlocks after a multiple of it since the deposit.
+	LockEpochs = 180
+
+	dataLength = 8
+)
+
+// Phase is the state of a DAO cell.
+type Phase int
+
+const (
+	// PhaseDeposited cells earn compensation until they are withdrawn.
+	PhaseDeposited Phase = iota + 1
+	// PhaseWithdrawing cells stopped earning compensation and unlock at their since.
+	PhaseWithdrawing
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseDeposited:
+		return "deposited"
+	case PhaseWithdrawing:
+		return "withdrawing"
+	}
+	return fmt.Sprintf("Phase(%d)", int(p))
+}
+
+// Cell is a DAO cell annotated with its phase and compensation.
+type Cell struct {
+	*indexer.LiveCell
+	Phase            Phase
+	DepositBlockHash types.Hash
+	// WithdrawBlockHash is the block of the phase-1 withdraw, zero for deposited cells.
+	WithdrawBlockHash types.Hash
+	// MaximumWithdraw is the capacity the cell unlocks: at the tip for deposited cells, at the withdraw block for withdrawing cells.
+	MaximumWithdraw uint64
+	Compensation    uint64
+	// Since is the earliest epoch a withdrawing cell unlocks at, zero for deposited cells.
+	Since uint64
+}
+
+// Script is the DAO type script of the chain and the cell dep of its code.
+type Script struct {
+	Type    *types.Script
+	CellDep *types.CellDep
+}
+
+// GetScript returns the DAO type script and cell dep of the genesis block.
+func GetScript(ctx context.Context, client rpc.Client) (*Script, error) {
+	registry, err := systemscript.FromGenesis(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return scriptOf(registry)
+}
+
+func scriptOf(registry *systemscript.Registry) (*Script, error) {
+	dao, err := registry.Get(systemscript.DAO)
+	if err != nil {
+		return nil, err
+	}
+	return &Script{
+		Type: &types.Script{
+			CodeHash: dao.CodeHash,
+			HashType: dao.HashType,
+			Args:     []byte{},
+		},
+		CellDep: dao.CellDep,
+	}, nil
+}
+
+// Matches reports whether the cell is a DAO cell.
+func (s *Script) Matches(output *types.CellOutput, data []byte) bool {
+	return output.Type != nil && output.Type.CodeHash == s.Type.CodeHash && output.Type.HashType == s.Type.HashType && len(data) == dataLength
+}
+
+// ListCells returns the DAO cells of the lock with their phase and compensation.
+func ListCells(ctx context.Context, client rpc.Client, lock *types.Script) ([]*Cell, error) {
+	script, err := GetScript(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	c := collector.NewCellCollector(client, &indexer.SearchKey{
+		Script:     lock,
+		ScriptType: indexer.ScriptTypeLock,
+	}, nil)
+	c.AllowTypeScript = true
+	c.AllowData = true
+	c.Skip = func(cell *indexer.LiveCell) bool {
+		return !script.Matches(cell.Output, cell.OutputData)
+	}
+	liveCells, err := c.Candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tip, err := client.GetTipHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]*Cell, len(liveCells))
+	for i, liveCell := range liveCells {
+		cells[i], err = annotate(ctx, client, liveCell, tip)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cells, nil
+}
+
+func annotate(ctx context.Context, client rpc.Client, liveCell *indexer.LiveCell, tip *types.Header) (*Cell, error) {
+	cell := &Cell{
+		LiveCell: liveCell,
+	}
+	depositNumber := binary.LittleEndian.Uint64(liveCell.OutputData)
+	withdrawHeader := tip
+	if depositNumber == 0 {
+		cell.Phase = PhaseDeposited
+		depositNumber = liveCell.BlockNumber
+	} else {
+		cell.Phase = PhaseWithdrawing
+		header, err := client.GetHeaderByNumber(ctx, liveCell.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		withdrawHeader = header
+		cell.WithdrawBlockHash = header.Hash
+	}
+	depositHeader, err := client.GetHeaderByNumber(ctx, depositNumber)
+	if err != nil {
+		return nil, err
+	}
+	cell.DepositBlockHash = depositHeader.Hash
+	if cell.Phase == PhaseWithdrawing {
+		cell.Since, err = UnlockSince(depositHeader, withdrawHeader)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cell.MaximumWithdraw, err = MaximumWithdraw(liveCell.Output, depositHeader, withdrawHeader)
+	if err != nil {
+		return nil, err
+	}
+	if cell.MaximumWithdraw > liveCell.Output.Capacity {
+		cell.Compensation = cell.MaximumWithdraw - liveCell.Output.Capacity
+	}
+	return cell, nil
+}
+
+// GetAPR estimates the annual percentage rate of deposits over the last blocks.
+func GetAPR(ctx context.Context, client rpc.Client, blocks uint64) (float64, error) {
+	tip, err := client.GetTipHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if blocks > tip.Number {
+		blocks = tip.Number
+	}
+	from, err := client.GetHeaderByNumber(ctx, tip.Number-blocks)
+	if err != nil {
+		return 0, err
+	}
+	return EstimateAPR(from, tip)
+}
+
+// UnlockSince returns the absolute epoch since a withdrawing cell unlocks at: the end of the first deposit
+// period of LockEpochs epochs which covers the withdraw block.
+func UnlockSince(depositHeader *types.Header, withdrawHeader *types.Header) (uint64, error) {
+	period := epoch.New(LockEpochs, 0, 1)
+	withdraw := epoch.FromHeader(withdrawHeader)
+	unlock := epoch.FromHeader(depositHeader).Add(period)
+	for unlock.Cmp(withdraw) < 0 {
+		unlock = unlock.Add(period)
+	}
+	return since.Epoch(false, unlock).Uint64()
+}
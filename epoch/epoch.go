@@ -0,0 +1,110 @@
+// Package epoch decodes the epoch with fraction of headers and since, compares and adds epochs, and estimates the
+// block number and time an epoch starts at.
+package epoch
+
+import (
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+const (
+	numberMask = 0xffffff
+	indexMask  = 0xffff
+	lengthMask = 0xffff
+)
+
+// Epoch is an epoch with fraction: Index blocks into the epoch Number of Length blocks.
+type Epoch struct {
+	Number uint64
+	Index  uint64
+	Length uint64
+}
+
+// New returns the epoch with fraction, a zero length is the start of the epoch.
+func New(number uint64, index uint64, length uint64) *Epoch {
+	if length == 0 {
+		index, length = 0, 1
+	}
+	return &Epoch{
+		Number: number,
+		Index:  index,
+		Length: length,
+	}
+}
+
+// Parse decodes a packed epoch with fraction, as headers and epoch since hold it: the number in the lowest 24 bits,
+// then the 16 bits index and the 16 bits length.
+func Parse(value uint64) *Epoch {
+	return New(value&numberMask, (value>>24)&indexMask, (value>>40)&lengthMask)
+}
+
+// WellFormed reports whether the packed epoch with fraction of a since is well formed, as the node verifies it:
+// its index is below its length, or both are zero for a whole number of epochs.
+func WellFormed(value uint64) bool {
+	index, length := (value>>24)&indexMask, (value>>40)&lengthMask
+	return index < length || index == 0 && length == 0
+}
+
+// FromHeader returns the epoch with fraction of the block.
+func FromHeader(header *types.Header) *Epoch {
+	return Parse(header.Epoch)
+}
+
+// AtBlock returns the epoch with fraction of a block of the epoch GetCurrentEpoch or GetEpochByNumber returned.
+func AtBlock(info *types.Epoch, number uint64) (*Epoch, error) {
+	if number < info.StartNumber || number >= info.StartNumber+info.Length {
+		return nil, fmt.Errorf("block %d is not in epoch %d", number, info.Number)
+	}
+	return New(info.Number, number-info.StartNumber, info.Length), nil
+}
+
+// Uint64 packs the epoch with fraction.
+func (e *Epoch) Uint64() uint64 {
+	return (e.Length&lengthMask)<<40 | (e.Index&indexMask)<<24 | e.Number&numberMask
+}
+
+func (e *Epoch) String() string {
+	return fmt.Sprintf("%d(%d/%d)", e.Number, e.Index, e.Length)
+}
+
+// Cmp compares the epochs as fractional numbers of epochs, returning -1, 0 or +1.
+func (e *Epoch) Cmp(other *Epoch) int {
+	e, other = e.normalized(), other.normalized()
+	switch {
+	case e.Number < other.Number:
+		return -1
+	case e.Number > other.Number:
+		return 1
+	}
+	left := e.Index * other.Length
+	right := other.Index * e.Length
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+	return 0
+}
+
+// Add returns the epoch a duration of epochs after e. The fraction of the duration is converted to the length of e,
+// rounding up so the sum is never earlier than the exact one.
+func (e *Epoch) Add(duration *Epoch) *Epoch {
+	e, duration = e.normalized(), duration.normalized()
+	index := duration.Index
+	if duration.Length != e.Length {
+		index = (duration.Index*e.Length + duration.Length - 1) / duration.Length
+	}
+	index += e.Index
+	return &Epoch{
+		Number: e.Number + duration.Number + index/e.Length,
+		Index:  index % e.Length,
+		Length: e.Length,
+	}
+}
+
+// normalized returns the epoch with a zero length counted as the start of the epoch.
+func (e *Epoch) normalized() *Epoch {
+	return New(e.Number, e.Index, e.Length)
+}
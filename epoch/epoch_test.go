@@ -0,0 +1,155 @@
+package epoch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value uint64
+		want  Epoch
+	}{
+		// epoch 180, block 7 of 10.
+		{0x00000a00070000b4, Epoch{180, 7, 10}},
+		{0x0007080000000001, Epoch{1, 0, 1800}},
+		// a zero length is the start of the epoch, as the epoch since of a number of whole epochs.
+		{0x0000000000000006, Epoch{6, 0, 1}},
+		{0x0000000005000006, Epoch{6, 0, 1}},
+	}
+	for _, tt := range tests {
+		e := Parse(tt.value)
+		if *e != tt.want {
+			t.Errorf("epoch %#x is %s, want %s", tt.value, e, &tt.want)
+		}
+	}
+	if value := New(180, 7, 10).Uint64(); value != 0x00000a00070000b4 {
+		t.Errorf("epoch 180(7/10) packs to %#x", value)
+	}
+}
+
+func TestCmp(t *testing.T) {
+	tests := []struct {
+		a, b *Epoch
+		want int
+	}{
+		{New(1, 0, 1), New(2, 0, 1), -1},
+		{New(2, 0, 1), New(1, 999, 1000), 1},
+		{New(1, 1, 2), New(1, 900, 1800), 0},
+		{New(1, 1, 2), New(1, 901, 1800), -1},
+		{&Epoch{1, 0, 0}, New(1, 0, 1000), 0},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Cmp(tt.b); got != tt.want {
+			t.Errorf("%s cmp %s is %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		e, duration *Epoch
+		want        Epoch
+	}{
+		{New(10, 500, 1000), New(180, 0, 1), Epoch{190, 500, 1000}},
+		{New(10, 900, 1000), New(0, 200, 1000), Epoch{11, 100, 1000}},
+		// a third of the epoch of 1000 blocks is rounded up to 334 blocks.
+		{New(10, 0, 1000), New(0, 1, 3), Epoch{10, 334, 1000}},
+		{New(10, 0, 1000), &Epoch{6, 0, 0}, Epoch{16, 0, 1000}},
+	}
+	for _, tt := range tests {
+		if got := tt.e.Add(tt.duration); *got != tt.want {
+			t.Errorf("%s add %s is %s, want %s", tt.e, tt.duration, got, &tt.want)
+		}
+	}
+}
+
+func TestBlockNumber(t *testing.T) {
+	current := &types.Epoch{Number: 10, StartNumber: 18000, Length: 1800}
+	tests := []struct {
+		target *Epoch
+		want   uint64
+	}{
+		{New(10, 900, 1800), 18900},
+		{New(12, 1, 2), 18000 + 2*1800 + 900},
+		// a packed epoch of zero length is the start of the epoch.
+		{&Epoch{11, 0, 0}, 19800},
+	}
+	for _, tt := range tests {
+		got, err := BlockNumber(current, tt.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("block number of %s is %d, want %d", tt.target, got, tt.want)
+		}
+	}
+	if _, err := BlockNumber(current, New(9, 0, 1)); err == nil {
+		t.Error("estimated the block number of an earlier epoch")
+	}
+}
+
+func TestTime(t *testing.T) {
+	tip := &types.Header{Epoch: New(10, 0, 1000).Uint64(), Timestamp: 1600000000000}
+	got := Time(tip, New(11, 1, 2))
+	want := time.Unix(1600000000, 0).Add(Duration + Duration/2)
+	if !got.Equal(want) {
+		t.Errorf("time is %s, want %s", got, want)
+	}
+}
+
+// epochsClient serves epochs of 1000 blocks, leaving the other methods unimplemented.
+type epochsClient struct {
+	rpc.Client
+	current uint64
+}
+
+func (c *epochsClient) GetCurrentEpoch(ctx context.Context) (*types.Epoch, error) {
+	return c.GetEpochByNumber(ctx, c.current)
+}
+
+func (c *epochsClient) GetEpochByNumber(ctx context.Context, number uint64) (*types.Epoch, error) {
+	return &types.Epoch{Number: number, StartNumber: number * 1000, Length: 1000}, nil
+}
+
+func TestEstimateBlockNumber(t *testing.T) {
+	client := &epochsClient{current: 10}
+	tests := []struct {
+		target *Epoch
+		want   uint64
+	}{
+		{New(5, 1, 4), 5250},
+		{&Epoch{5, 0, 0}, 5000},
+		{New(12, 1, 2), 12500},
+	}
+	for _, tt := range tests {
+		got, err := EstimateBlockNumber(context.Background(), client, tt.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("block number of %s is %d, want %d", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestWellFormed(t *testing.T) {
+	tests := []struct {
+		value uint64
+		want  bool
+	}{
+		{New(180, 7, 10).Uint64(), true},
+		{0x0000000000000006, true},
+		{0x0000000001000006, false},
+		{0x00000a000a000006, false},
+	}
+	for _, tt := range tests {
+		if got := WellFormed(tt.value); got != tt.want {
+			t.Errorf("epoch %#x well formed is %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package epoch
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+// Duration is the target duration of an epoch, the difficulty adjustment keeps epochs close to it.
+const Duration = 4 * time.Hour
+
+// BlockNumber estimates the block number of the epoch with fraction from the current epoch, assuming the next
+// epochs have its length. An epoch before the current epoch is an error, its start is returned by GetEpochByNumber.
+func BlockNumber(current *types.Epoch, target *Epoch) (uint64, error) {
+	target = target.normalized()
+	if target.Number < current.Number {
+		return 0, fmt.Errorf("epoch %d is before the current epoch %d", target.Number, current.Number)
+	}
+	offset := (target.Number-current.Number)*current.Length + target.Index*current.Length/target.Length
+	return current.StartNumber + offset, nil
+}
+
+// Time estimates the time of the epoch with fraction from the tip, assuming every epoch lasts Duration. Earlier
+// epochs give a time before the tip.
+func Time(tip *types.Header, target *Epoch) time.Time {
+	from := FromHeader(tip)
+	epochs := new(big.Rat).Sub(rat(target), rat(from))
+	nanoseconds, _ := new(big.Rat).Mul(epochs, new(big.Rat).SetInt64(int64(Duration))).Float64()
+	return time.Unix(0, int64(tip.Timestamp)*int64(time.Millisecond)).Add(time.Duration(nanoseconds))
+}
+
+// EstimateBlockNumber estimates the block number of the epoch with fraction from the current epoch of the chain.
+func EstimateBlockNumber(ctx context.Context, client rpc.Client, target *Epoch) (uint64, error) {
+	current, err := client.GetCurrentEpoch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	target = target.normalized()
+	if target.Number < current.Number {
+		info, err := client.GetEpochByNumber(ctx, target.Number)
+		if err != nil {
+			return 0, err
+		}
+		return info.StartNumber + target.Index*info.Length/target.Length, nil
+	}
+	return BlockNumber(current, target)
+}
+
+// EstimateTime estimates the time of the epoch with fraction from the tip of the chain.
+func EstimateTime(ctx context.Context, client rpc.Client, target *Epoch) (time.Time, error) {
+	tip, err := client.GetTipHeader(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return Time(tip, target), nil
+}
+
+func rat(e *Epoch) *big.Rat {
+	e = e.normalized()
+	r := new(big.Rat).SetInt(new(big.Int).SetUint64(e.Number))
+	return r.Add(r, new(big.Rat).SetFrac(new(big.Int).SetUint64(e.Index), new(big.Int).SetUint64(e.Length)))
+}
@@ -0,0 +1,249 @@
+// Package feerate estimates fee rates from the transactions of recent blocks, for nodes which do not support
+// EstimateFeeRate.
+package feerate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/builder"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+const (
+	// DefaultBlocks is the number of recent blocks sampled.
+	DefaultBlocks = 20
+	// DefaultTTL is how long an estimate is reused before the recent blocks are sampled again.
+	DefaultTTL = 30 * time.Second
+)
+
+// Speed is a target confirmation speed.
+type Speed int
+
+const (
+	// Slow pays the rate of the 10th percentile of the recent transactions.
+	Slow Speed = iota
+	// Normal pays the median rate of the recent transactions.
+	Normal
+	// Fast pays the rate of the 90th percentile of the recent transactions.
+	Fast
+)
+
+func (s Speed) percentile() float64 {
+	switch s {
+	case Slow:
+		return 10
+	case Fast:
+		return 90
+	}
+	return 50
+}
+
+// Rates are the fee rates in shannons per KB of the transactions of the recent blocks.
+type Rates struct {
+	// TipNumber is the newest sampled block.
+	TipNumber uint64
+	// Samples are the fee rates of the sampled transactions in ascending order.
+	Samples []uint64
+	// Minimum is the lowest rate returned, such as the minimum fee rate of the node.
+	Minimum uint64
+}
+
+// Percentile returns the fee rate at the percentile of the samples, from 0 to 100, and at least the minimum.
+func (r *Rates) Percentile(p float64) uint64 {
+	if len(r.Samples) == 0 {
+		return r.Minimum
+	}
+	rank := int(math.Ceil(p/100*float64(len(r.Samples)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(r.Samples) {
+		rank = len(r.Samples) - 1
+	}
+	if r.Samples[rank] < r.Minimum {
+		return r.Minimum
+	}
+	return r.Samples[rank]
+}
+
+// FeeRate returns the fee rate of the confirmation speed.
+func (r *Rates) FeeRate(speed Speed) uint64 {
+	return r.Percentile(speed.percentile())
+}
+
+// Estimator samples the recent blocks for the fee rates their transactions paid. The rates of a block are cached by
+// block hash, so a new estimate only resolves the inputs of the blocks mined since the last one.
+type Estimator struct {
+	client rpc.Client
+	// Blocks is the number of recent blocks sampled.
+	Blocks int
+	// TTL is how long an estimate is reused.
+	TTL time.Duration
+	// Minimum is the lowest fee rate estimated, defaults to builder.DefaultFeeRate.
+	Minimum uint64
+	// BatchSize is the number of transactions looked up in one GetTransaction batch call.
+	BatchSize int
+
+	mu          sync.Mutex
+	rates       *Rates
+	estimatedAt time.Time
+	blocks      map[types.Hash][]uint64
+}
+
+func NewEstimator(client rpc.Client) *Estimator {
+	return &Estimator{
+		client:    client,
+		Blocks:    DefaultBlocks,
+		TTL:       DefaultTTL,
+		Minimum:   builder.DefaultFeeRate,
+		BatchSize: rpc.AggregateBatchSize,
+		blocks:    make(map[types.Hash][]uint64),
+	}
+}
+
+// FeeRate returns the estimated fee rate of the confirmation speed.
+func (e *Estimator) FeeRate(ctx context.Context, speed Speed) (uint64, error) {
+	rates, err := e.Estimate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return rates.FeeRate(speed), nil
+}
+
+// Estimate returns the fee rates of the transactions of the recent blocks, the cached ones within TTL. The blocks
+// are sampled without holding the cache, concurrent calls after the TTL may sample them each.
+func (e *Estimator) Estimate(ctx context.Context) (*Rates, error) {
+	e.mu.Lock()
+	if e.rates != nil && time.Since(e.estimatedAt) < e.TTL {
+		rates := e.rates
+		e.mu.Unlock()
+		return rates, nil
+	}
+	// the cache map is replaced, never written, so the sampled blocks are read without the lock.
+	cached := e.blocks
+	e.mu.Unlock()
+
+	tip, err := e.client.GetTipBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rates := &Rates{
+		TipNumber: tip,
+		Minimum:   e.Minimum,
+	}
+	blocks := make(map[types.Hash][]uint64, e.Blocks)
+	for i := uint64(0); i < uint64(e.Blocks) && i <= tip; i++ {
+		block, err := e.client.GetBlockByNumber(ctx, tip-i)
+		if err != nil {
+			return nil, err
+		}
+		samples, ok := cached[block.Header.Hash]
+		if !ok {
+			samples, err = e.blockRates(ctx, block)
+			if err != nil {
+				return nil, err
+			}
+		}
+		blocks[block.Header.Hash] = samples
+		rates.Samples = append(rates.Samples, samples...)
+	}
+	sort.Slice(rates.Samples, func(i, j int) bool {
+		return rates.Samples[i] < rates.Samples[j]
+	})
+
+	e.mu.Lock()
+	e.blocks = blocks
+	e.rates = rates
+	e.estimatedAt = time.Now()
+	e.mu.Unlock()
+	return rates, nil
+}
+
+// blockRates returns the fee rates of the transactions of the block but the cellbase, resolving their inputs with
+// batch calls. Transactions with header deps are not sampled: DAO withdraws load the headers of their deposits and
+// create the compensation, so their capacity difference is not a fee.
+func (e *Estimator) blockRates(ctx context.Context, block *types.Block) ([]uint64, error) {
+	var txs []*types.Transaction
+	var batch []types.BatchTransactionItem
+	seen := make(map[types.Hash]bool)
+	for _, tx := range block.Transactions[1:] {
+		if len(tx.HeaderDeps) > 0 {
+			continue
+		}
+		txs = append(txs, tx)
+		for _, input := range tx.Inputs {
+			if !seen[input.PreviousOutput.TxHash] {
+				seen[input.PreviousOutput.TxHash] = true
+				batch = append(batch, types.BatchTransactionItem{Hash: input.PreviousOutput.TxHash})
+			}
+		}
+	}
+	err := e.batchTransactions(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	previous := make(map[types.Hash]*types.Transaction, len(batch))
+	for _, item := range batch {
+		if item.Result == nil || item.Result.Transaction == nil {
+			return nil, fmt.Errorf("transaction %s not found", item.Hash.String())
+		}
+		previous[item.Hash] = item.Result.Transaction
+	}
+
+	var samples []uint64
+	for _, tx := range txs {
+		var inputs uint64
+		for _, input := range tx.Inputs {
+			prev := previous[input.PreviousOutput.TxHash]
+			if input.PreviousOutput.Index >= uint(len(prev.Outputs)) {
+				return nil, fmt.Errorf("transaction %s has no output %d", input.PreviousOutput.TxHash.String(), input.PreviousOutput.Index)
+			}
+			inputs += prev.Outputs[input.PreviousOutput.Index].Capacity
+		}
+		var outputs uint64
+		for _, output := range tx.Outputs {
+			outputs += output.Capacity
+		}
+		if inputs <= outputs {
+			continue
+		}
+
+		size, err := transaction.Size(tx)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, (inputs-outputs)*1000/size)
+	}
+	return samples, nil
+}
+
+// batchTransactions looks the transactions up with one GetTransaction batch call per BatchSize transactions.
+func (e *Estimator) batchTransactions(ctx context.Context, batch []types.BatchTransactionItem) error {
+	size := e.BatchSize
+	if size <= 0 {
+		size = rpc.AggregateBatchSize
+	}
+	for start := 0; start < len(batch); start += size {
+		end := start + size
+		if end > len(batch) {
+			end = len(batch)
+		}
+		err := e.client.BatchTransactions(ctx, batch[start:end])
+		if err != nil {
+			return err
+		}
+		for _, item := range batch[start:end] {
+			if item.Error != nil {
+				return fmt.Errorf("get transaction %s error: %v", item.Hash.String(), item.Error)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package feerate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+// chainClient serves the blocks and the transactions they spend, leaving the other methods unimplemented.
+type chainClient struct {
+	rpc.Client
+	blocks       []*types.Block
+	transactions map[types.Hash]*types.Transaction
+	batches      int
+}
+
+func (c *chainClient) GetTipBlockNumber(ctx context.Context) (uint64, error) {
+	return uint64(len(c.blocks) - 1), nil
+}
+
+func (c *chainClient) GetBlockByNumber(ctx context.Context, number uint64) (*types.Block, error) {
+	return c.blocks[number], nil
+}
+
+func (c *chainClient) BatchTransactions(ctx context.Context, batch []types.BatchTransactionItem) error {
+	c.batches++
+	for i := range batch {
+		tx, ok := c.transactions[batch[i].Hash]
+		if !ok {
+			batch[i].Result = &types.TransactionWithStatus{}
+			continue
+		}
+		batch[i].Result = &types.TransactionWithStatus{Transaction: tx}
+	}
+	return nil
+}
+
+var (
+	previousTx = types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541")
+	missingTx  = types.HexToHash("0x4bb9b6b2d37ac4fe8fd2be4ac3bf12e2a4d52baf9f0dcfce9da6bc4a7e6ab3b6")
+)
+
+// spending returns a transaction spending the output of previousTx, of 1000 CKB each, with an output of capacity.
+func spending(index uint, capacity uint64) *types.Transaction {
+	return &types.Transaction{
+		Inputs:      []*types.CellInput{{PreviousOutput: &types.OutPoint{TxHash: previousTx, Index: index}}},
+		Outputs:     []*types.CellOutput{{Capacity: capacity, Lock: &types.Script{HashType: types.HashTypeType, Args: []byte{}}}},
+		OutputsData: [][]byte{{}},
+		Witnesses:   [][]byte{{}},
+	}
+}
+
+func testClient(transactions ...*types.Transaction) *chainClient {
+	previous := &types.Transaction{}
+	for i := 0; i < 3; i++ {
+		previous.Outputs = append(previous.Outputs, &types.CellOutput{Capacity: 1000 * 100000000})
+	}
+	cellbase := &types.Transaction{}
+	return &chainClient{
+		blocks: []*types.Block{
+			{Header: &types.Header{Hash: types.HexToHash("0x01")}, Transactions: []*types.Transaction{cellbase}},
+			{Header: &types.Header{Hash: types.HexToHash("0x02")}, Transactions: append([]*types.Transaction{cellbase}, transactions...)},
+		},
+		transactions: map[types.Hash]*types.Transaction{previousTx: previous},
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	cheap := spending(0, 1000*100000000-1000)
+	expensive := spending(1, 1000*100000000-100000)
+	// a DAO withdraw loads the deposit header, the compensation it also spends makes its capacity difference no fee.
+	withdraw := spending(2, 1000*100000000-500)
+	withdraw.HeaderDeps = []types.Hash{types.HexToHash("0x03")}
+	client := testClient(cheap, expensive, withdraw)
+
+	e := NewEstimator(client)
+	e.Minimum = 0
+	rates, err := e.Estimate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, err := transaction.Size(cheap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint64{1000 * 1000 / size, 100000 * 1000 / size}
+	if len(rates.Samples) != 2 || rates.Samples[0] != want[0] || rates.Samples[1] != want[1] {
+		t.Fatalf("samples are %v, want %v", rates.Samples, want)
+	}
+	if rates.FeeRate(Slow) != want[0] || rates.FeeRate(Fast) != want[1] {
+		t.Errorf("slow and fast rates are %d and %d, want %v", rates.FeeRate(Slow), rates.FeeRate(Fast), want)
+	}
+	if client.batches != 1 {
+		t.Errorf("resolved the inputs with %d batch calls, want 1", client.batches)
+	}
+
+	if cached, err := e.Estimate(context.Background()); err != nil || cached != rates {
+		t.Errorf("estimate within TTL returned %v, %v", cached, err)
+	}
+	e.TTL = 0
+	if _, err := e.Estimate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if client.batches != 1 {
+		t.Errorf("sampled cached blocks again with %d batch calls", client.batches)
+	}
+}
+
+func TestEstimateMinimum(t *testing.T) {
+	e := NewEstimator(testClient())
+	rates, err := e.Estimate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates.FeeRate(Normal) != e.Minimum {
+		t.Errorf("rate of empty blocks is %d, want the minimum %d", rates.FeeRate(Normal), e.Minimum)
+	}
+}
+
+func TestEstimateErrors(t *testing.T) {
+	missing := spending(0, 1)
+	missing.Inputs[0].PreviousOutput.TxHash = missingTx
+	tests := []struct {
+		name string
+		tx   *types.Transaction
+	}{
+		{"missing transaction", missing},
+		{"output out of range", spending(3, 1)},
+	}
+	for _, tt := range tests {
+		_, err := NewEstimator(testClient(tt.tx)).Estimate(context.Background())
+		if err == nil {
+			t.Errorf("%s: estimated without error", tt.name)
+		}
+	}
+
+	client := &failingClient{testClient(spending(0, 1))}
+	if _, err := NewEstimator(client).Estimate(context.Background()); err == nil {
+		t.Error("estimated with a failed batch item")
+	}
+}
+
+// failingClient fails every item of the batch calls.
+type failingClient struct {
+	*chainClient
+}
+
+func (c *failingClient) BatchTransactions(ctx context.Context, batch []types.BatchTransactionItem) error {
+	for i := range batch {
+		batch[i].Error = errors.New("unavailable")
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package indexer
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// refer BatchElem from go-ethereum
+type BatchGetCellsItem struct {
+	SearchKey   *SearchKey
+	Order       SearchOrder
+	Limit       uint64
+	AfterCursor string
+	Result      *LiveCells
+	Error       error
+}
+
+type BatchGetTransactionsItem struct {
+	SearchKey   *SearchKey
+	Order       SearchOrder
+	Limit       uint64
+	AfterCursor string
+	Result      *Transactions
+	Error       error
+}
+
+type BatchGetCellsCapacityItem struct {
+	SearchKey *SearchKey
+	Result    *Capacity
+	Error     error
+}
+
+type BatchGetTipItem struct {
+	Result *TipHeader
+	Error  error
+}
+
+// BatchRequest collects indexer requests which are sent together in a single JSON-RPC batch call.
+// Every Add method returns an item whose Result or Error is filled once the batch has been sent.
+type BatchRequest struct {
+	elems     []rpc.BatchElem
+	callbacks []func(elem rpc.BatchElem)
+}
+
+func NewBatchRequest() *BatchRequest {
+	return &BatchRequest{}
+}
+
+// Len returns the number of requests in the batch.
+func (b *BatchRequest) Len() int {
+	return len(b.elems)
+}
+
+func (b *BatchRequest) AddGetCells(searchKey *SearchKey, order SearchOrder, limit uint64, afterCursor string) *BatchGetCellsItem {
+	item := &BatchGetCellsItem{
+		SearchKey:   searchKey,
+		Order:       order,
+		Limit:       limit,
+		AfterCursor: afterCursor,
+	}
+	b.add("get_cells", &liveCells{}, pageArgs(searchKey, order, limit, afterCursor), func(elem rpc.BatchElem) {
+		item.Error = elem.Error
+		if item.Error == nil {
+			item.Result = toLiveCells(*elem.Result.(*liveCells))
+		}
+	})
+	return item
+}
+
+func (b *BatchRequest) AddGetTransactions(searchKey *SearchKey, order SearchOrder, limit uint64, afterCursor string) *BatchGetTransactionsItem {
+	item := &BatchGetTransactionsItem{
+		SearchKey:   searchKey,
+		Order:       order,
+		Limit:       limit,
+		AfterCursor: afterCursor,
+	}
+	b.add("get_transactions", &transactions{}, pageArgs(searchKey, order, limit, afterCursor), func(elem rpc.BatchElem) {
+		item.Error = elem.Error
+		if item.Error == nil {
+			item.Result = toTransactions(*elem.Result.(*transactions))
+		}
+	})
+	return item
+}
+
+func (b *BatchRequest) AddGetCellsCapacity(searchKey *SearchKey) *BatchGetCellsCapacityItem {
+	item := &BatchGetCellsCapacityItem{
+		SearchKey: searchKey,
+	}
+	b.add("get_cells_capacity", &capacity{}, []interface{}{fromSearchKey(searchKey)}, func(elem rpc.BatchElem) {
+		item.Error = elem.Error
+		if item.Error == nil {
+			result := elem.Result.(*capacity)
+			item.Result = &Capacity{
+				Capacity:    uint64(result.Capacity),
+				BlockHash:   result.BlockHash,
+				BlockNumber: uint64(result.BlockNumber),
+			}
+		}
+	})
+	return item
+}
+
+func (b *BatchRequest) AddGetTip() *BatchGetTipItem {
+	item := &BatchGetTipItem{}
+	b.add("get_tip", &tipHeader{}, nil, func(elem rpc.BatchElem) {
+		item.Error = elem.Error
+		if item.Error == nil {
+			result := elem.Result.(*tipHeader)
+			item.Result = &TipHeader{
+				BlockHash:   result.BlockHash,
+				BlockNumber: uint64(result.BlockNumber),
+			}
+		}
+	})
+	return item
+}
+
+func (b *BatchRequest) add(method string, result interface{}, args []interface{}, callback func(elem rpc.BatchElem)) {
+	b.elems = append(b.elems, rpc.BatchElem{
+		Method: method,
+		Args:   args,
+		Result: result,
+	})
+	b.callbacks = append(b.callbacks, callback)
+}
+
+func (b *BatchRequest) done() {
+	for i, elem := range b.elems {
+		b.callbacks[i](elem)
+	}
+}
+
+func pageArgs(searchKey *SearchKey, order SearchOrder, limit uint64, afterCursor string) []interface{} {
+	args := []interface{}{fromSearchKey(searchKey), order, hexutil.Uint64(limit)}
+	if afterCursor != "" {
+		args = append(args, afterCursor)
+	}
+	return args
+}
@@ -20,6 +20,15 @@ type Client interface {
 	//GetCellsCapacity returns the live cells capacity by the lock or type script.
 	GetCellsCapacity(ctx context.Context, searchKey *SearchKey) (*Capacity, error)
 
+	// BatchGetCells returns the live cells collections of several search keys in a single batch call.
+	BatchGetCells(ctx context.Context, batch []BatchGetCellsItem) error
+
+	// BatchGetCellsCapacity returns the live cells capacities of several search keys in a single batch call.
+	BatchGetCellsCapacity(ctx context.Context, batch []BatchGetCellsCapacityItem) error
+
+	// BatchCall sends all requests collected by the batch request in a single batch call.
+	BatchCall(ctx context.Context, batch *BatchRequest) error
+
 	// Close close client
 	Close()
 }
@@ -100,3 +109,55 @@ func (cli *client) GetCellsCapacity(ctx context.Context, searchKey *SearchKey) (
 		BlockNumber: uint64(result.BlockNumber),
 	}, nil
 }
+
+func (cli *client) BatchGetCells(ctx context.Context, batch []BatchGetCellsItem) error {
+	req := NewBatchRequest()
+	items := make([]*BatchGetCellsItem, len(batch))
+	for i, item := range batch {
+		items[i] = req.AddGetCells(item.SearchKey, item.Order, item.Limit, item.AfterCursor)
+	}
+
+	err := cli.BatchCall(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		batch[i].Result = item.Result
+		batch[i].Error = item.Error
+	}
+	return nil
+}
+
+func (cli *client) BatchGetCellsCapacity(ctx context.Context, batch []BatchGetCellsCapacityItem) error {
+	req := NewBatchRequest()
+	items := make([]*BatchGetCellsCapacityItem, len(batch))
+	for i, item := range batch {
+		items[i] = req.AddGetCellsCapacity(item.SearchKey)
+	}
+
+	err := cli.BatchCall(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		batch[i].Result = item.Result
+		batch[i].Error = item.Error
+	}
+	return nil
+}
+
+func (cli *client) BatchCall(ctx context.Context, batch *BatchRequest) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	err := cli.c.BatchCallContext(ctx, batch.elems)
+	if err != nil {
+		return err
+	}
+
+	batch.done()
+	return nil
+}
@@ -0,0 +1,246 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+const (
+	cellTx   = "0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541"
+	tipBlock = "0x4bb9b6b2d37ac4fe8fd2be4ac3bf12e2a4d52baf9f0dcfce9da6bc4a7e6ab3b6"
+)
+
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// indexerServer answers single and batched JSON-RPC requests with the handler of their method,
+// counting the HTTP requests it receives.
+type indexerServer struct {
+	requests int
+	handlers map[string]func(params []json.RawMessage) (interface{}, error)
+}
+
+func (s *indexerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests++
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		var requests []request
+		if err := json.Unmarshal(body, &requests); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		responses := make([]response, len(requests))
+		for i, req := range requests {
+			responses[i] = s.answer(req)
+		}
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(s.answer(req))
+}
+
+func (s *indexerServer) answer(req request) response {
+	resp := response{Version: "2.0", ID: req.ID}
+	handler, ok := s.handlers[req.Method]
+	if !ok {
+		resp.Error = &responseError{Code: -32601, Message: "method not found"}
+		return resp
+	}
+	result, err := handler(req.Params)
+	if err != nil {
+		resp.Error = &responseError{Code: -32000, Message: err.Error()}
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+var (
+	lock = &types.Script{
+		CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
+		HashType: types.HashTypeType,
+		Args:     common.FromHex("0xedcda9513fa030ce4308e29245a22c022d0443bb"),
+	}
+	unknown = &types.Script{
+		CodeHash: lock.CodeHash,
+		HashType: lock.HashType,
+		Args:     common.FromHex("0xc8328aabcd9b9e8e64fbc566c4385c3bdeb219d7"),
+	}
+)
+
+// testClient returns a client of an indexer holding one 100 CKB cell of lock at block 16. Its get_cells echoes the
+// cursor as the last cursor and fails for other scripts.
+func testClient(t *testing.T) (Client, *indexerServer) {
+	script := func(params []json.RawMessage) error {
+		var key searchKey
+		if err := json.Unmarshal(params[0], &key); err != nil {
+			return err
+		}
+		if !bytes.Equal(key.Script.Args, lock.Args) {
+			return errors.New("unknown script")
+		}
+		return nil
+	}
+	s := &indexerServer{handlers: map[string]func(params []json.RawMessage) (interface{}, error){
+		"get_tip": func(params []json.RawMessage) (interface{}, error) {
+			return map[string]string{"block_hash": tipBlock, "block_number": "0x10"}, nil
+		},
+		"get_cells": func(params []json.RawMessage) (interface{}, error) {
+			if err := script(params); err != nil {
+				return nil, err
+			}
+			cursor := "0x01"
+			if len(params) == 4 {
+				if err := json.Unmarshal(params[3], &cursor); err != nil {
+					return nil, err
+				}
+			}
+			cell := map[string]interface{}{
+				"block_number": "0x10",
+				"out_point":    map[string]string{"tx_hash": cellTx, "index": "0x1"},
+				"output": map[string]interface{}{
+					"capacity": "0x2540be400",
+					"lock":     map[string]string{"code_hash": lock.CodeHash.String(), "hash_type": "type", "args": "0xedcda9513fa030ce4308e29245a22c022d0443bb"},
+				},
+				"output_data": "0x",
+				"tx_index":    "0x2",
+			}
+			return map[string]interface{}{"last_cursor": cursor, "objects": []interface{}{cell}}, nil
+		},
+		"get_cells_capacity": func(params []json.RawMessage) (interface{}, error) {
+			if err := script(params); err != nil {
+				return nil, err
+			}
+			return map[string]string{"capacity": "0x2540be400", "block_hash": tipBlock, "block_number": "0x10"}, nil
+		},
+	}}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	c, err := rpc.DialHTTP(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(c), s
+}
+
+func checkCells(t *testing.T, cells *LiveCells, cursor string) {
+	if len(cells.Objects) != 1 || cells.LastCursor != cursor {
+		t.Fatalf("got %d cells and cursor %s", len(cells.Objects), cells.LastCursor)
+	}
+	cell := cells.Objects[0]
+	if cell.BlockNumber != 16 || cell.TxIndex != 2 || cell.OutPoint.TxHash.String() != cellTx || cell.OutPoint.Index != 1 ||
+		cell.Output.Capacity != 100*100000000 || !bytes.Equal(cell.Output.Lock.Args, lock.Args) {
+		t.Errorf("decoded cell %+v", cell)
+	}
+}
+
+func TestBatchCall(t *testing.T) {
+	client, server := testClient(t)
+	batch := NewBatchRequest()
+	cells := batch.AddGetCells(&SearchKey{Script: lock, ScriptType: ScriptTypeLock}, SearchOrderAsc, 10, "")
+	failed := batch.AddGetCells(&SearchKey{Script: unknown, ScriptType: ScriptTypeLock}, SearchOrderAsc, 10, "")
+	capacity := batch.AddGetCellsCapacity(&SearchKey{Script: lock, ScriptType: ScriptTypeLock})
+	tip := batch.AddGetTip()
+	if batch.Len() != 4 {
+		t.Fatalf("batch has %d requests", batch.Len())
+	}
+	if err := client.BatchCall(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if server.requests != 1 {
+		t.Errorf("sent the batch in %d requests", server.requests)
+	}
+
+	if cells.Error != nil {
+		t.Fatal(cells.Error)
+	}
+	checkCells(t, cells.Result, "0x01")
+	// a failing request leaves the other requests of the batch unaffected.
+	if failed.Error == nil || failed.Result != nil {
+		t.Errorf("cells of an unknown script are %v, %v", failed.Result, failed.Error)
+	}
+	if capacity.Error != nil || capacity.Result.Capacity != 100*100000000 || capacity.Result.BlockNumber != 16 {
+		t.Errorf("capacity is %+v, %v", capacity.Result, capacity.Error)
+	}
+	if tip.Error != nil || tip.Result.BlockNumber != 16 || tip.Result.BlockHash.String() != tipBlock {
+		t.Errorf("tip is %+v, %v", tip.Result, tip.Error)
+	}
+
+	if err := client.BatchCall(context.Background(), NewBatchRequest()); err != nil || server.requests != 1 {
+		t.Errorf("empty batch sent a request with %v", err)
+	}
+}
+
+func TestBatchGetCells(t *testing.T) {
+	client, server := testClient(t)
+	batch := []BatchGetCellsItem{
+		{SearchKey: &SearchKey{Script: lock, ScriptType: ScriptTypeLock}, Order: SearchOrderDesc, Limit: 10, AfterCursor: "0x02"},
+		{SearchKey: &SearchKey{Script: unknown, ScriptType: ScriptTypeLock}, Order: SearchOrderDesc, Limit: 10},
+	}
+	if err := client.BatchGetCells(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if server.requests != 1 {
+		t.Errorf("sent the batch in %d requests", server.requests)
+	}
+	if batch[0].Error != nil {
+		t.Fatal(batch[0].Error)
+	}
+	checkCells(t, batch[0].Result, "0x02")
+	if batch[1].Error == nil {
+		t.Error("got the cells of an unknown script")
+	}
+}
+
+func TestBatchGetCellsCapacity(t *testing.T) {
+	client, server := testClient(t)
+	batch := []BatchGetCellsCapacityItem{
+		{SearchKey: &SearchKey{Script: unknown, ScriptType: ScriptTypeLock}},
+		{SearchKey: &SearchKey{Script: lock, ScriptType: ScriptTypeLock}},
+	}
+	if err := client.BatchGetCellsCapacity(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if server.requests != 1 {
+		t.Errorf("sent the batch in %d requests", server.requests)
+	}
+	if batch[0].Error == nil {
+		t.Error("got the capacity of an unknown script")
+	}
+	if batch[1].Error != nil || batch[1].Result.Capacity != 100*100000000 {
+		t.Errorf("capacity is %+v, %v", batch[1].Result, batch[1].Error)
+	}
+}
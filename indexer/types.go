@@ -1,6 +1,8 @@
 package indexer
 
 import (
+	"bytes"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 )
@@ -188,3 +190,41 @@ func fromSearchKey(key *SearchKey) *searchKey {
 
 	return result
 }
+
+// Match reports whether the output is found by the search key, the search key script args being a prefix of the cell script args.
+func (key *SearchKey) Match(output *types.CellOutput) bool {
+	script := output.Lock
+	if key.ScriptType == ScriptTypeType {
+		script = output.Type
+	}
+	if script == nil || script.CodeHash != key.Script.CodeHash || script.HashType != key.Script.HashType {
+		return false
+	}
+	if key.ArgsLen > 0 && uint(len(script.Args)) != key.ArgsLen {
+		return false
+	}
+	return bytes.HasPrefix(script.Args, key.Script.Args)
+}
+
+// CompareLiveCells orders live cells the way the indexer does, by block number, transaction index and output index.
+func CompareLiveCells(a *LiveCell, b *LiveCell) int {
+	if a.BlockNumber != b.BlockNumber {
+		if a.BlockNumber < b.BlockNumber {
+			return -1
+		}
+		return 1
+	}
+	if a.TxIndex != b.TxIndex {
+		if a.TxIndex < b.TxIndex {
+			return -1
+		}
+		return 1
+	}
+	if a.OutPoint.Index != b.OutPoint.Index {
+		if a.OutPoint.Index < b.OutPoint.Index {
+			return -1
+		}
+		return 1
+	}
+	return bytes.Compare(a.OutPoint.TxHash.Bytes(), b.OutPoint.TxHash.Bytes())
+}
@@ -0,0 +1,364 @@
+package mempool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+// DefaultTTL is how long a tracked transaction is kept when it never gets committed.
+const DefaultTTL = 30 * time.Minute
+
+// Overlay tracks the transactions sent through it and overlays them on the indexer results:
+// GetCells and GetCellsCapacity hide the cells spent by tracked transactions and include their outputs
+// as pending cells. Pending cells have a zero BlockNumber.
+//
+// The node RPC does not list pool transactions, so transactions sent by other clients are only
+// overlaid once registered with Track.
+type Overlay struct {
+	rpc.Client
+	// TTL is how long a tracked transaction is kept when it never gets committed.
+	TTL time.Duration
+
+	mu  sync.RWMutex
+	txs map[types.Hash]*pendingTx
+	// spent maps the out points consumed by tracked transactions to the consumed cell.
+	spent map[string]*types.CellOutput
+	// created maps the out points created by tracked transactions to the pending cell.
+	created map[string]*indexer.LiveCell
+}
+
+type pendingTx struct {
+	transaction *types.Transaction
+	inputs      []*types.CellOutput
+	trackedAt   time.Time
+	// committedAt is the number of the block which committed the transaction, zero while pending.
+	committedAt uint64
+}
+
+func NewOverlay(client rpc.Client) *Overlay {
+	return &Overlay{
+		Client:  client,
+		TTL:     DefaultTTL,
+		txs:     make(map[types.Hash]*pendingTx),
+		spent:   make(map[string]*types.CellOutput),
+		created: make(map[string]*indexer.LiveCell),
+	}
+}
+
+// SendTransaction sends the transaction to the node and tracks it once accepted.
+func (o *Overlay) SendTransaction(ctx context.Context, tx *types.Transaction) (*types.Hash, error) {
+	inputs, err := o.resolveInputs(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := o.Client.SendTransaction(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	o.track(*hash, tx, inputs)
+	return hash, nil
+}
+
+// SendTransactionNoneValidation sends the transaction to the node skipping outputs validation and tracks it once accepted.
+func (o *Overlay) SendTransactionNoneValidation(ctx context.Context, tx *types.Transaction) (*types.Hash, error) {
+	inputs, err := o.resolveInputs(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := o.Client.SendTransactionNoneValidation(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	o.track(*hash, tx, inputs)
+	return hash, nil
+}
+
+// Track overlays a transaction already sent to the node, such as one sent by another client.
+func (o *Overlay) Track(ctx context.Context, tx *types.Transaction) error {
+	hash, err := tx.ComputeHash()
+	if err != nil {
+		return err
+	}
+	inputs, err := o.resolveInputs(ctx, tx)
+	if err != nil {
+		return err
+	}
+	o.track(hash, tx, inputs)
+	return nil
+}
+
+// IsPending reports whether the out point is created by a tracked transaction not yet seen by the indexer.
+func (o *Overlay) IsPending(outPoint *types.OutPoint) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	_, ok := o.created[outPointKey(outPoint)]
+	return ok
+}
+
+// Pending returns the hashes of the tracked transactions.
+func (o *Overlay) Pending() []types.Hash {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	hashes := make([]types.Hash, 0, len(o.txs))
+	for hash := range o.txs {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// Refresh drops the tracked transactions which were rejected or evicted by the node, expired,
+// or committed in a block the indexer has processed.
+func (o *Overlay) Refresh(ctx context.Context) error {
+	tip, err := o.Client.GetTip(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the tracked transactions are copied with their state, committedAt is only read and written under the lock.
+	o.mu.RLock()
+	txs := make(map[types.Hash]pendingTx, len(o.txs))
+	for hash, tx := range o.txs {
+		txs[hash] = *tx
+	}
+	o.mu.RUnlock()
+
+	var dropped []types.Hash
+	for hash, tx := range txs {
+		committedAt := tx.committedAt
+		if committedAt == 0 {
+			status, err := o.Client.GetTransaction(ctx, hash)
+			if err != nil {
+				return err
+			}
+			switch status.TxStatus.Status {
+			case types.TransactionStatusPending, types.TransactionStatusProposed:
+				if time.Since(tx.trackedAt) > o.TTL {
+					dropped = append(dropped, hash)
+				}
+				continue
+			case types.TransactionStatusCommitted:
+				header, err := o.Client.GetHeader(ctx, *status.TxStatus.BlockHash)
+				if err != nil {
+					return err
+				}
+				committedAt = header.Number
+				o.mu.Lock()
+				if tracked, ok := o.txs[hash]; ok {
+					tracked.committedAt = committedAt
+				}
+				o.mu.Unlock()
+			default:
+				dropped = append(dropped, hash)
+				continue
+			}
+		}
+		if tip.BlockNumber >= committedAt {
+			dropped = append(dropped, hash)
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, hash := range dropped {
+		o.untrack(hash)
+	}
+	return nil
+}
+
+// Run refreshes the overlay every interval until the context is done or a refresh fails.
+func (o *Overlay) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := o.Refresh(ctx)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetCells returns the indexer live cells without the cells spent by tracked transactions.
+// The pending cells matching the search key are appended to the last page in ascending order,
+// or prepended to the first page in descending order.
+func (o *Overlay) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	cells, err := o.Client.GetCells(ctx, searchKey, order, limit, afterCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	result := &indexer.LiveCells{
+		LastCursor: cells.LastCursor,
+	}
+	if order == indexer.SearchOrderDesc && afterCursor == "" {
+		result.Objects = append(result.Objects, o.pendingCells(searchKey)...)
+	}
+	for _, cell := range cells.Objects {
+		if _, ok := o.spent[outPointKey(cell.OutPoint)]; !ok {
+			result.Objects = append(result.Objects, cell)
+		}
+	}
+	if order != indexer.SearchOrderDesc && uint64(len(cells.Objects)) < limit {
+		result.Objects = append(result.Objects, o.pendingCells(searchKey)...)
+	}
+	return result, nil
+}
+
+// GetCellsCapacity returns the indexer capacity minus the cells spent by tracked transactions,
+// plus the pending cells matching the search key.
+func (o *Overlay) GetCellsCapacity(ctx context.Context, searchKey *indexer.SearchKey) (*indexer.Capacity, error) {
+	capacity, err := o.Client.GetCellsCapacity(ctx, searchKey)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	var spent uint64
+	for op, output := range o.spent {
+		if _, ok := o.created[op]; ok {
+			// spending a pending cell, the indexer does not know it
+			continue
+		}
+		if searchKey.Match(output) {
+			spent += output.Capacity
+		}
+	}
+	result := &indexer.Capacity{
+		BlockHash:   capacity.BlockHash,
+		BlockNumber: capacity.BlockNumber,
+	}
+	// the indexer may have processed a committed transaction before the next Refresh
+	if spent < capacity.Capacity {
+		result.Capacity = capacity.Capacity - spent
+	}
+	for _, cell := range o.pendingCells(searchKey) {
+		result.Capacity += cell.Output.Capacity
+	}
+	return result, nil
+}
+
+// resolveInputs returns the cells consumed by the transaction, from the tracked transactions or the node.
+func (o *Overlay) resolveInputs(ctx context.Context, tx *types.Transaction) ([]*types.CellOutput, error) {
+	inputs := make([]*types.CellOutput, len(tx.Inputs))
+	var batch []types.BatchLiveCellItem
+	var indexes []int
+
+	o.mu.RLock()
+	for i, input := range tx.Inputs {
+		if cell, ok := o.created[outPointKey(input.PreviousOutput)]; ok {
+			inputs[i] = cell.Output
+			continue
+		}
+		batch = append(batch, types.BatchLiveCellItem{
+			OutPoint: *input.PreviousOutput,
+		})
+		indexes = append(indexes, i)
+	}
+	o.mu.RUnlock()
+
+	if len(batch) == 0 {
+		return inputs, nil
+	}
+	err := o.Client.BatchLiveCells(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range batch {
+		if item.Error != nil {
+			return nil, item.Error
+		}
+		if item.Result.Cell == nil {
+			return nil, fmt.Errorf("input %s:%d is %s", item.OutPoint.TxHash.String(), item.OutPoint.Index, item.Result.Status)
+		}
+		inputs[indexes[i]] = item.Result.Cell.Output
+	}
+	return inputs, nil
+}
+
+func (o *Overlay) track(hash types.Hash, tx *types.Transaction, inputs []*types.CellOutput) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.txs[hash] = &pendingTx{
+		transaction: tx,
+		inputs:      inputs,
+		trackedAt:   time.Now(),
+	}
+	for i, input := range tx.Inputs {
+		o.spent[outPointKey(input.PreviousOutput)] = inputs[i]
+	}
+	for i, output := range tx.Outputs {
+		cell := &indexer.LiveCell{
+			OutPoint: &types.OutPoint{
+				TxHash: hash,
+				Index:  uint(i),
+			},
+			Output:     output,
+			OutputData: tx.OutputsData[i],
+		}
+		o.created[outPointKey(cell.OutPoint)] = cell
+	}
+}
+
+// untrack drops a tracked transaction. When the transaction never got committed,
+// the tracked transactions spending its outputs are dropped as well.
+func (o *Overlay) untrack(hash types.Hash) {
+	tx, ok := o.txs[hash]
+	if !ok {
+		return
+	}
+	delete(o.txs, hash)
+	for _, input := range tx.transaction.Inputs {
+		delete(o.spent, outPointKey(input.PreviousOutput))
+	}
+	for i := range tx.transaction.Outputs {
+		delete(o.created, outPointKey(&types.OutPoint{TxHash: hash, Index: uint(i)}))
+	}
+	if tx.committedAt != 0 {
+		return
+	}
+
+	for childHash, child := range o.txs {
+		for _, input := range child.transaction.Inputs {
+			if input.PreviousOutput.TxHash == hash {
+				o.untrack(childHash)
+				break
+			}
+		}
+	}
+}
+
+func (o *Overlay) pendingCells(searchKey *indexer.SearchKey) []*indexer.LiveCell {
+	var cells []*indexer.LiveCell
+	for op, cell := range o.created {
+		if _, ok := o.spent[op]; ok {
+			continue
+		}
+		if searchKey.Match(cell.Output) {
+			cells = append(cells, cell)
+		}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		return indexer.CompareLiveCells(cells[i], cells[j]) < 0
+	})
+	return cells
+}
+
+func outPointKey(outPoint *types.OutPoint) string {
+	return fmt.Sprintf("%s:%d", outPoint.TxHash.String(), outPoint.Index)
+}
@@ -0,0 +1,290 @@
+package mempool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+var indexedTx = types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541")
+
+// chainClient serves the indexed cells, the status of the sent transactions and the indexer tip, leaving the other
+// methods unimplemented.
+type chainClient struct {
+	rpc.Client
+	cells    []*indexer.LiveCell
+	statuses map[types.Hash]types.TxStatus
+	tip      uint64
+	// headerDelay is the time GetHeader takes, as a node round trip does.
+	headerDelay time.Duration
+}
+
+func (c *chainClient) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	var cells []*indexer.LiveCell
+	for _, cell := range c.cells {
+		if searchKey.Match(cell.Output) {
+			cells = append(cells, cell)
+		}
+	}
+	if order == indexer.SearchOrderDesc {
+		for i, j := 0, len(cells)-1; i < j; i, j = i+1, j-1 {
+			cells[i], cells[j] = cells[j], cells[i]
+		}
+	}
+	return &indexer.LiveCells{Objects: cells}, nil
+}
+
+func (c *chainClient) GetCellsCapacity(ctx context.Context, searchKey *indexer.SearchKey) (*indexer.Capacity, error) {
+	capacity := &indexer.Capacity{}
+	for _, cell := range c.cells {
+		if searchKey.Match(cell.Output) {
+			capacity.Capacity += cell.Output.Capacity
+		}
+	}
+	return capacity, nil
+}
+
+func (c *chainClient) BatchLiveCells(ctx context.Context, batch []types.BatchLiveCellItem) error {
+	for i := range batch {
+		batch[i].Result = &types.CellWithStatus{Status: "unknown"}
+		for _, cell := range c.cells {
+			if *cell.OutPoint == batch[i].OutPoint {
+				batch[i].Result = &types.CellWithStatus{Cell: &types.CellInfo{Output: cell.Output}, Status: "live"}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *chainClient) SendTransaction(ctx context.Context, tx *types.Transaction) (*types.Hash, error) {
+	hash, err := tx.ComputeHash()
+	if err != nil {
+		return nil, err
+	}
+	c.statuses[hash] = types.TxStatus{Status: types.TransactionStatusPending}
+	return &hash, nil
+}
+
+func (c *chainClient) GetTransaction(ctx context.Context, hash types.Hash) (*types.TransactionWithStatus, error) {
+	status := c.statuses[hash]
+	return &types.TransactionWithStatus{TxStatus: &status}, nil
+}
+
+func (c *chainClient) GetHeader(ctx context.Context, hash types.Hash) (*types.Header, error) {
+	time.Sleep(c.headerDelay)
+	return &types.Header{Hash: hash, Number: 5}, nil
+}
+
+func (c *chainClient) GetTip(ctx context.Context) (*indexer.TipHeader, error) {
+	return &indexer.TipHeader{BlockNumber: c.tip}, nil
+}
+
+func lock(args string) *types.Script {
+	return &types.Script{
+		CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
+		HashType: types.HashTypeType,
+		Args:     common.FromHex(args),
+	}
+}
+
+var (
+	owner = lock("0xedcda9513fa030ce4308e29245a22c022d0443bb")
+	other = lock("0xc8328aabcd9b9e8e64fbc566c4385c3bdeb219d7")
+)
+
+// testClient returns a client indexing two cells of the owner, of 100 and 200 CKB.
+func testClient() *chainClient {
+	return &chainClient{
+		cells: []*indexer.LiveCell{
+			{BlockNumber: 1, OutPoint: &types.OutPoint{TxHash: indexedTx, Index: 0}, Output: &types.CellOutput{Capacity: 100 * 100000000, Lock: owner}},
+			{BlockNumber: 1, OutPoint: &types.OutPoint{TxHash: indexedTx, Index: 1}, Output: &types.CellOutput{Capacity: 200 * 100000000, Lock: owner}},
+		},
+		statuses: make(map[types.Hash]types.TxStatus),
+	}
+}
+
+// spend returns a transaction spending the out point into cells of the capacities, the first one to the owner and the
+// others to another lock.
+func spend(outPoint *types.OutPoint, capacities ...uint64) *types.Transaction {
+	tx := &types.Transaction{
+		CellDeps:   []*types.CellDep{},
+		HeaderDeps: []types.Hash{},
+		Inputs:     []*types.CellInput{{PreviousOutput: outPoint}},
+		Witnesses:  [][]byte{},
+	}
+	for i, capacity := range capacities {
+		output := &types.CellOutput{Capacity: capacity, Lock: other}
+		if i == 0 {
+			output.Lock = owner
+		}
+		tx.Outputs = append(tx.Outputs, output)
+		tx.OutputsData = append(tx.OutputsData, []byte{})
+	}
+	return tx
+}
+
+func ownerCells(t *testing.T, o *Overlay, order indexer.SearchOrder) []uint64 {
+	cells, err := o.GetCells(context.Background(), &indexer.SearchKey{Script: owner, ScriptType: indexer.ScriptTypeLock}, order, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	capacities := make([]uint64, len(cells.Objects))
+	for i, cell := range cells.Objects {
+		capacities[i] = cell.Output.Capacity
+	}
+	return capacities
+}
+
+func ownerCapacity(t *testing.T, o *Overlay) uint64 {
+	capacity, err := o.GetCellsCapacity(context.Background(), &indexer.SearchKey{Script: owner, ScriptType: indexer.ScriptTypeLock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return capacity.Capacity
+}
+
+func equal(a []uint64, b ...uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestOverlay(t *testing.T) {
+	client := testClient()
+	o := NewOverlay(client)
+	parent, err := o.SendTransaction(context.Background(), spend(&types.OutPoint{TxHash: indexedTx, Index: 0}, 60*100000000, 3999999000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.IsPending(&types.OutPoint{TxHash: *parent, Index: 0}) {
+		t.Error("output of the sent transaction is not pending")
+	}
+	if got := ownerCells(t, o, indexer.SearchOrderAsc); !equal(got, 200*100000000, 60*100000000) {
+		t.Errorf("ascending owner cells are %v", got)
+	}
+	if got := ownerCells(t, o, indexer.SearchOrderDesc); !equal(got, 60*100000000, 200*100000000) {
+		t.Errorf("descending owner cells are %v", got)
+	}
+	if got := ownerCapacity(t, o); got != 260*100000000 {
+		t.Errorf("owner capacity is %d", got)
+	}
+
+	// the child spends a pending cell, which the indexer does not count.
+	child := spend(&types.OutPoint{TxHash: *parent, Index: 0}, 5999999000)
+	if err := o.Track(context.Background(), child); err != nil {
+		t.Fatal(err)
+	}
+	if got := ownerCells(t, o, indexer.SearchOrderAsc); !equal(got, 200*100000000, 5999999000) {
+		t.Errorf("owner cells after the child are %v", got)
+	}
+	if got := ownerCapacity(t, o); got != 200*100000000+5999999000 {
+		t.Errorf("owner capacity after the child is %d", got)
+	}
+	if err := o.Track(context.Background(), spend(&types.OutPoint{TxHash: *parent, Index: 2}, 1)); err == nil {
+		t.Error("tracked a transaction spending an unknown cell")
+	}
+
+	// the parent is rejected, so the child can never be committed either.
+	client.statuses[*parent] = types.TxStatus{Status: types.TransactionStatus("rejected")}
+	if err := o.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(o.Pending()) != 0 {
+		t.Errorf("kept %d transactions of a rejected parent", len(o.Pending()))
+	}
+	if got := ownerCapacity(t, o); got != 300*100000000 {
+		t.Errorf("owner capacity after the rejection is %d", got)
+	}
+}
+
+func TestOverlayRefresh(t *testing.T) {
+	client := testClient()
+	o := NewOverlay(client)
+	hash, err := o.SendTransaction(context.Background(), spend(&types.OutPoint{TxHash: indexedTx, Index: 1}, 19999999000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(o.Pending()) != 1 {
+		t.Fatal("dropped a pending transaction")
+	}
+
+	// committed in block 5, the transaction is kept until the indexer reaches that block.
+	blockHash := types.HexToHash("0x4bb9b6b2d37ac4fe8fd2be4ac3bf12e2a4d52baf9f0dcfce9da6bc4a7e6ab3b6")
+	client.statuses[*hash] = types.TxStatus{Status: types.TransactionStatusCommitted, BlockHash: &blockHash}
+	client.tip = 4
+	if err := o.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(o.Pending()) != 1 {
+		t.Fatal("dropped a transaction committed beyond the indexer tip")
+	}
+	client.tip = 5
+	if err := o.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(o.Pending()) != 0 {
+		t.Error("kept a transaction the indexer has processed")
+	}
+
+	o.TTL = 0
+	if _, err := o.SendTransaction(context.Background(), spend(&types.OutPoint{TxHash: indexedTx, Index: 0}, 9999999000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(o.Pending()) != 0 {
+		t.Error("kept an expired transaction")
+	}
+}
+
+func TestOverlayConcurrentRefresh(t *testing.T) {
+	client := testClient()
+	o := NewOverlay(client)
+	hash, err := o.SendTransaction(context.Background(), spend(&types.OutPoint{TxHash: indexedTx, Index: 1}, 19999999000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHash := types.HexToHash("0x4bb9b6b2d37ac4fe8fd2be4ac3bf12e2a4d52baf9f0dcfce9da6bc4a7e6ab3b6")
+	client.statuses[*hash] = types.TxStatus{Status: types.TransactionStatusCommitted, BlockHash: &blockHash}
+	client.tip = 4
+	client.headerDelay = time.Millisecond
+
+	// refreshes record the commit block while others read it, run with -race.
+	start := make(chan struct{})
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			<-start
+			if err := o.Refresh(context.Background()); err != nil {
+				errs <- err
+				return
+			}
+			_, err := o.GetCellsCapacity(context.Background(), &indexer.SearchKey{Script: owner, ScriptType: indexer.ScriptTypeLock})
+			errs <- err
+		}()
+	}
+	close(start)
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(o.Pending()) != 1 {
+		t.Error("dropped a transaction committed beyond the indexer tip")
+	}
+}
@@ -0,0 +1,156 @@
+// Package molecule encodes CKB types with the molecule serialization and computes their blake2b hashes offline.
+package molecule
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const u32Size = 4
+
+// Uint32 encodes n as a little-endian Uint32.
+func Uint32(n uint32) []byte {
+	b := make([]byte, u32Size)
+	binary.LittleEndian.PutUint32(b, n)
+	return b
+}
+
+// Uint64 encodes n as a little-endian Uint64.
+func Uint64(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, n)
+	return b
+}
+
+// Bytes encodes a fixvec of bytes: the item count followed by the items.
+func Bytes(data []byte) []byte {
+	b := make([]byte, 0, u32Size+len(data))
+	b = append(b, Uint32(uint32(len(data)))...)
+	return append(b, data...)
+}
+
+// Struct encodes fixed size fields one after another.
+func Struct(fields ...[]byte) []byte {
+	var b []byte
+	for _, field := range fields {
+		b = append(b, field...)
+	}
+	return b
+}
+
+// FixVec encodes a vector of fixed size items: the item count followed by the items.
+func FixVec(items [][]byte) []byte {
+	b := Uint32(uint32(len(items)))
+	for _, item := range items {
+		b = append(b, item...)
+	}
+	return b
+}
+
+// DynVec encodes a vector of dynamic size items: the full size, the item offsets, then the items.
+func DynVec(items [][]byte) []byte {
+	return Table(items...)
+}
+
+// Table encodes dynamic size fields: the full size, the field offsets, then the fields.
+func Table(fields ...[]byte) []byte {
+	header := u32Size * (1 + len(fields))
+	size := header
+	for _, field := range fields {
+		size += len(field)
+	}
+
+	b := make([]byte, 0, size)
+	b = append(b, Uint32(uint32(size))...)
+	offset := header
+	for _, field := range fields {
+		b = append(b, Uint32(uint32(offset))...)
+		offset += len(field)
+	}
+	for _, field := range fields {
+		b = append(b, field...)
+	}
+	return b
+}
+
+// Option encodes an optional value, nil encodes to nothing.
+func Option(data []byte) []byte {
+	if data == nil {
+		return []byte{}
+	}
+	return data
+}
+
+// ParseTable splits a table or dynvec into its fields.
+func ParseTable(data []byte) ([][]byte, error) {
+	if len(data) < u32Size {
+		return nil, errors.New("molecule: table header is too short")
+	}
+	size := int(binary.LittleEndian.Uint32(data))
+	if size != len(data) {
+		return nil, fmt.Errorf("molecule: table size is %d but %d bytes are given", size, len(data))
+	}
+	if size == u32Size {
+		return [][]byte{}, nil
+	}
+	if size < 2*u32Size {
+		return nil, errors.New("molecule: table header is too short")
+	}
+	first := int(binary.LittleEndian.Uint32(data[u32Size:]))
+	if first%u32Size != 0 || first < 2*u32Size || first > size {
+		return nil, fmt.Errorf("molecule: invalid first offset %d", first)
+	}
+
+	count := first/u32Size - 1
+	offsets := make([]int, count+1)
+	for i := 0; i < count; i++ {
+		offsets[i] = int(binary.LittleEndian.Uint32(data[u32Size*(i+1):]))
+	}
+	offsets[count] = size
+
+	fields := make([][]byte, count)
+	for i := 0; i < count; i++ {
+		if offsets[i] > offsets[i+1] || offsets[i] < first {
+			return nil, fmt.Errorf("molecule: invalid offset %d", offsets[i])
+		}
+		fields[i] = data[offsets[i]:offsets[i+1]]
+	}
+	return fields, nil
+}
+
+// ParseBytes decodes a fixvec of bytes.
+func ParseBytes(data []byte) ([]byte, error) {
+	if len(data) < u32Size {
+		return nil, errors.New("molecule: bytes header is too short")
+	}
+	length := int(binary.LittleEndian.Uint32(data))
+	if length != len(data)-u32Size {
+		return nil, fmt.Errorf("molecule: bytes length is %d but %d bytes are given", length, len(data)-u32Size)
+	}
+	return data[u32Size:], nil
+}
+
+// BytesVec encodes a dynvec of bytes.
+func BytesVec(items [][]byte) []byte {
+	encoded := make([][]byte, len(items))
+	for i, item := range items {
+		encoded[i] = Bytes(item)
+	}
+	return DynVec(encoded)
+}
+
+// ParseBytesVec decodes a dynvec of bytes.
+func ParseBytesVec(data []byte) ([][]byte, error) {
+	items, err := ParseTable(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		items[i], err = ParseBytes(item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,216 @@
+package molecule
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+const (
+	// mainnetGenesisTx is the first transaction of the mainnet genesis block, holding the system script cells.
+	mainnetGenesisTx = "0xe2fb199810d49a4d8beec56718ba2593b665db9d52299a0f9e6e75416d73ff5c"
+	// mainnetDepGroupTx is the second transaction of the mainnet genesis block, holding the dep group cells.
+	mainnetDepGroupTx = "0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c"
+	typeIDCodeHash    = "0x00000000000000000000000000000000000000000000000000545950455f4944"
+)
+
+func TestScriptHash(t *testing.T) {
+	// the type scripts of the mainnet genesis system cells hash to the code hashes of the system scripts.
+	tests := []struct {
+		name string
+		args string
+		hash string
+	}{
+		{"secp256k1-blake160", "0x8536c9d5d908bd89fc70099e4284870708b6632356aad98734fcf43f6f71c304", "0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"},
+		{"DAO", "0xb2a8500929d6a1294bf9bf1bf565f549fa4a5f1316a3306ad3d4783e64bcf626", "0x82d76d1b75fe2fd9a27dfbaa65a039221a380d76c926f378d3f81cf3e7e13f2e"},
+		{"multisig", "0xd813c1b15bd79c8321ad7f5819e5d9f659a1042b72e64659a2c092be68ea9758", "0x5c5069eb0857efc65e1bca0c07df34c31663b3622fd3876c876320fc9634e2a8"},
+	}
+	for _, tt := range tests {
+		hash, err := ScriptHash(&types.Script{
+			CodeHash: types.HexToHash(typeIDCodeHash),
+			HashType: types.HashTypeType,
+			Args:     common.FromHex(tt.args),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash.String() != tt.hash {
+			t.Errorf("%s type script hash is %s, want %s", tt.name, hash.String(), tt.hash)
+		}
+	}
+}
+
+func TestSerializeScript(t *testing.T) {
+	script := &types.Script{
+		CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
+		HashType: types.HashTypeType,
+		Args:     common.FromHex("0xedcda9513fa030ce4308e29245a22c022d0443bb"),
+	}
+	data, err := SerializeScript(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "49000000100000003000000031000000" +
+		"9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8" +
+		"01" + "14000000edcda9513fa030ce4308e29245a22c022d0443bb"
+	if hex.EncodeToString(data) != want {
+		t.Errorf("serialized script is %x, want %s", data, want)
+	}
+
+	decoded, err := DeserializeScript(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.CodeHash != script.CodeHash || decoded.HashType != script.HashType || !bytes.Equal(decoded.Args, script.Args) {
+		t.Errorf("decoded script %+v, want %+v", decoded, script)
+	}
+}
+
+func TestSerializeWitnessArgs(t *testing.T) {
+	// the WitnessArgs of a zero-filled secp256k1 signature, the placeholder the sighash message is computed over.
+	data := SerializeWitnessArgs(&types.WitnessArgs{Lock: make([]byte, 65)})
+	want := "55000000100000005500000055000000" + "41000000" + hex.EncodeToString(make([]byte, 65))
+	if hex.EncodeToString(data) != want {
+		t.Errorf("serialized witness args are %x, want %s", data, want)
+	}
+
+	decoded, err := DeserializeWitnessArgs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Lock) != 65 || decoded.InputType != nil || decoded.OutputType != nil {
+		t.Errorf("decoded witness args %+v", decoded)
+	}
+}
+
+func TestSerializeRawTransactionEmpty(t *testing.T) {
+	data, err := SerializeRawTransaction(&types.Transaction{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "340000001c000000200000002400000028000000" + "2c00000030000000" +
+		"00000000" + "00000000" + "00000000" + "00000000" + "04000000" + "04000000"
+	if hex.EncodeToString(data) != want {
+		t.Errorf("serialized raw transaction is %x, want %s", data, want)
+	}
+}
+
+func TestHash(t *testing.T) {
+	// the ckb-default-hash of no data is a published CKB constant, independent of the upstream SDK.
+	hash, err := Hash(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash.String() != "0x44f4c69744d5f8c55d642062949dcae49bc4e7ef43d388c5a12f42b5633d163e" {
+		t.Errorf("hash of no data is %s", hash.String())
+	}
+}
+
+func TestTransactionHash(t *testing.T) {
+	lock := &types.Script{
+		CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
+		HashType: types.HashTypeType,
+		Args:     common.FromHex("0xedcda9513fa030ce4308e29245a22c022d0443bb"),
+	}
+	tx := &types.Transaction{
+		Version: 0,
+		CellDeps: []*types.CellDep{
+			{OutPoint: &types.OutPoint{TxHash: types.HexToHash(mainnetDepGroupTx), Index: 0}, DepType: types.DepTypeDepGroup},
+			{OutPoint: &types.OutPoint{TxHash: types.HexToHash(mainnetGenesisTx), Index: 2}, DepType: types.DepTypeCode},
+		},
+		HeaderDeps: []types.Hash{types.HexToHash("0x92b197aa1fba0f63633922c61c92375c9c074a93e85963554f5499fe1450d0e5")},
+		Inputs: []*types.CellInput{
+			{Since: 0, PreviousOutput: &types.OutPoint{TxHash: types.HexToHash(mainnetGenesisTx), Index: 7}},
+			{Since: 0x20000a00070000b4, PreviousOutput: &types.OutPoint{TxHash: types.HexToHash(mainnetDepGroupTx), Index: 1}},
+		},
+		Outputs: []*types.CellOutput{
+			{
+				Capacity: 10200000000,
+				Lock:     lock,
+				Type: &types.Script{
+					CodeHash: types.HexToHash("0x82d76d1b75fe2fd9a27dfbaa65a039221a380d76c926f378d3f81cf3e7e13f2e"),
+					HashType: types.HashTypeType,
+					Args:     []byte{},
+				},
+			},
+			{Capacity: 6100000000, Lock: lock},
+		},
+		OutputsData: [][]byte{make([]byte, 8), {}},
+		Witnesses:   [][]byte{SerializeWitnessArgs(&types.WitnessArgs{Lock: make([]byte, 65)}), {}},
+	}
+
+	// the upstream SDK encodes the raw transaction independently.
+	raw, err := SerializeRawTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstreamRaw, err := tx.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, upstreamRaw) {
+		t.Errorf("raw transaction is %x, upstream encodes %x", raw, upstreamRaw)
+	}
+	hash, err := TransactionHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstreamHash, err := tx.ComputeHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != upstreamHash {
+		t.Errorf("transaction hash is %s, upstream computes %s", hash.String(), upstreamHash.String())
+	}
+
+	// the witnesses follow the raw transaction and are not hashed.
+	data, err := SerializeTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := ParseTable(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 2 || !bytes.Equal(fields[0], raw) {
+		t.Fatalf("transaction does not start with its raw transaction")
+	}
+	witnesses, err := ParseTable(fields[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(witnesses) != 2 || len(witnesses[0]) != 4+85 || len(witnesses[1]) != 4 {
+		t.Errorf("got %d witnesses", len(witnesses))
+	}
+	tx.Witnesses = nil
+	unsigned, err := TransactionHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsigned != hash {
+		t.Errorf("witnesses change the transaction hash")
+	}
+}
+
+func TestDeserializeOutPointVec(t *testing.T) {
+	// the data of the mainnet secp256k1-blake160 dep group cell lists the script and the secp256k1 data cells.
+	data := "02000000" +
+		mainnetGenesisTx[2:] + "01000000" +
+		mainnetGenesisTx[2:] + "03000000"
+	outPoints, err := DeserializeOutPointVec(common.FromHex(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outPoints) != 2 || outPoints[0].TxHash.String() != mainnetGenesisTx || outPoints[0].Index != 1 || outPoints[1].Index != 3 {
+		t.Fatalf("got out points %+v", outPoints)
+	}
+	if hex.EncodeToString(SerializeOutPoint(outPoints[1])) != data[len(data)-72:] {
+		t.Errorf("serialized out point is %x", SerializeOutPoint(outPoints[1]))
+	}
+	if _, err := DeserializeOutPointVec(common.FromHex(data[:len(data)-2])); err == nil {
+		t.Error("decoded a truncated out point vector")
+	}
+}
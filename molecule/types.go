@@ -0,0 +1,287 @@
+package molecule
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// HashTypeData1 is the hash type of scripts referenced by data hash and run by the second VM version.
+const HashTypeData1 types.ScriptHashType = "data1"
+
+const outPointSize = types.HashLength + u32Size
+
+func SerializeHashType(hashType types.ScriptHashType) ([]byte, error) {
+	switch hashType {
+	case types.HashTypeData:
+		return []byte{0}, nil
+	case types.HashTypeType:
+		return []byte{1}, nil
+	case HashTypeData1:
+		return []byte{2}, nil
+	}
+	return nil, fmt.Errorf("molecule: invalid script hash type %q", hashType)
+}
+
+func DeserializeHashType(data byte) (types.ScriptHashType, error) {
+	switch data {
+	case 0:
+		return types.HashTypeData, nil
+	case 1:
+		return types.HashTypeType, nil
+	case 2:
+		return HashTypeData1, nil
+	}
+	return "", fmt.Errorf("molecule: invalid script hash type %d", data)
+}
+
+func SerializeDepType(depType types.DepType) ([]byte, error) {
+	switch depType {
+	case types.DepTypeCode:
+		return []byte{0}, nil
+	case types.DepTypeDepGroup:
+		return []byte{1}, nil
+	}
+	return nil, fmt.Errorf("molecule: invalid dep type %q", depType)
+}
+
+func SerializeScript(script *types.Script) ([]byte, error) {
+	hashType, err := SerializeHashType(script.HashType)
+	if err != nil {
+		return nil, err
+	}
+	return Table(script.CodeHash.Bytes(), hashType, Bytes(script.Args)), nil
+}
+
+func DeserializeScript(data []byte) (*types.Script, error) {
+	fields, err := ParseTable(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("molecule: Script has 3 fields but %d are given", len(fields))
+	}
+	if len(fields[0]) != types.HashLength || len(fields[1]) != 1 {
+		return nil, errors.New("molecule: invalid Script code hash or hash type")
+	}
+	hashType, err := DeserializeHashType(fields[1][0])
+	if err != nil {
+		return nil, err
+	}
+	args, err := ParseBytes(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	return &types.Script{
+		CodeHash: types.BytesToHash(fields[0]),
+		HashType: hashType,
+		Args:     args,
+	}, nil
+}
+
+func SerializeScriptVec(scripts []*types.Script) ([]byte, error) {
+	items := make([][]byte, len(scripts))
+	for i, script := range scripts {
+		b, err := SerializeScript(script)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = b
+	}
+	return DynVec(items), nil
+}
+
+func DeserializeScriptVec(data []byte) ([]*types.Script, error) {
+	items, err := ParseTable(data)
+	if err != nil {
+		return nil, err
+	}
+	scripts := make([]*types.Script, len(items))
+	for i, item := range items {
+		scripts[i], err = DeserializeScript(item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return scripts, nil
+}
+
+// SerializeScriptOpt encodes an optional script, such as a cell type script.
+func SerializeScriptOpt(script *types.Script) ([]byte, error) {
+	if script == nil {
+		return Option(nil), nil
+	}
+	return SerializeScript(script)
+}
+
+func SerializeOutPoint(outPoint *types.OutPoint) []byte {
+	return Struct(outPoint.TxHash.Bytes(), Uint32(uint32(outPoint.Index)))
+}
+
+// DeserializeOutPointVec decodes the out points of a dep group cell data.
+func DeserializeOutPointVec(data []byte) ([]*types.OutPoint, error) {
+	if len(data) < u32Size {
+		return nil, errors.New("molecule: out point vector header is too short")
+	}
+	count := int(binary.LittleEndian.Uint32(data))
+	if len(data) != u32Size+count*outPointSize {
+		return nil, fmt.Errorf("molecule: out point vector of %d items is %d bytes", count, len(data))
+	}
+	outPoints := make([]*types.OutPoint, count)
+	for i := range outPoints {
+		item := data[u32Size+i*outPointSize:]
+		outPoints[i] = &types.OutPoint{
+			TxHash: types.BytesToHash(item[:types.HashLength]),
+			Index:  uint(binary.LittleEndian.Uint32(item[types.HashLength:])),
+		}
+	}
+	return outPoints, nil
+}
+
+func SerializeCellInput(input *types.CellInput) []byte {
+	return Struct(Uint64(input.Since), SerializeOutPoint(input.PreviousOutput))
+}
+
+func SerializeCellOutput(output *types.CellOutput) ([]byte, error) {
+	lock, err := SerializeScript(output.Lock)
+	if err != nil {
+		return nil, err
+	}
+	typeScript, err := SerializeScriptOpt(output.Type)
+	if err != nil {
+		return nil, err
+	}
+	return Table(Uint64(output.Capacity), lock, typeScript), nil
+}
+
+func SerializeCellDep(dep *types.CellDep) ([]byte, error) {
+	depType, err := SerializeDepType(dep.DepType)
+	if err != nil {
+		return nil, err
+	}
+	return Struct(SerializeOutPoint(dep.OutPoint), depType), nil
+}
+
+// SerializeRawTransaction encodes the transaction without its witnesses, the part hashed into the transaction hash.
+func SerializeRawTransaction(tx *types.Transaction) ([]byte, error) {
+	cellDeps := make([][]byte, len(tx.CellDeps))
+	for i, dep := range tx.CellDeps {
+		b, err := SerializeCellDep(dep)
+		if err != nil {
+			return nil, err
+		}
+		cellDeps[i] = b
+	}
+	headerDeps := make([][]byte, len(tx.HeaderDeps))
+	for i, hash := range tx.HeaderDeps {
+		headerDeps[i] = hash.Bytes()
+	}
+	inputs := make([][]byte, len(tx.Inputs))
+	for i, input := range tx.Inputs {
+		inputs[i] = SerializeCellInput(input)
+	}
+	outputs := make([][]byte, len(tx.Outputs))
+	for i, output := range tx.Outputs {
+		b, err := SerializeCellOutput(output)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = b
+	}
+	outputsData := make([][]byte, len(tx.OutputsData))
+	for i, data := range tx.OutputsData {
+		outputsData[i] = Bytes(data)
+	}
+
+	return Table(
+		Uint32(uint32(tx.Version)),
+		FixVec(cellDeps),
+		FixVec(headerDeps),
+		FixVec(inputs),
+		DynVec(outputs),
+		DynVec(outputsData),
+	), nil
+}
+
+// SerializeTransaction encodes the raw transaction and its witnesses.
+func SerializeTransaction(tx *types.Transaction) ([]byte, error) {
+	raw, err := SerializeRawTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	witnesses := make([][]byte, len(tx.Witnesses))
+	for i, witness := range tx.Witnesses {
+		witnesses[i] = Bytes(witness)
+	}
+	return Table(raw, DynVec(witnesses)), nil
+}
+
+func SerializeWitnessArgs(witnessArgs *types.WitnessArgs) []byte {
+	return Table(optionBytes(witnessArgs.Lock), optionBytes(witnessArgs.InputType), optionBytes(witnessArgs.OutputType))
+}
+
+// DeserializeWitnessArgs decodes a witness encoded as WitnessArgs, an empty witness decodes to empty WitnessArgs.
+func DeserializeWitnessArgs(data []byte) (*types.WitnessArgs, error) {
+	if len(data) == 0 {
+		return &types.WitnessArgs{}, nil
+	}
+	fields, err := ParseTable(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("molecule: WitnessArgs has 3 fields but %d are given", len(fields))
+	}
+	values := make([][]byte, 3)
+	for i, field := range fields {
+		if len(field) == 0 {
+			continue
+		}
+		values[i], err = ParseBytes(field)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &types.WitnessArgs{
+		Lock:       values[0],
+		InputType:  values[1],
+		OutputType: values[2],
+	}, nil
+}
+
+// TransactionHash returns the blake2b hash of the raw transaction.
+func TransactionHash(tx *types.Transaction) (types.Hash, error) {
+	raw, err := SerializeRawTransaction(tx)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return Hash(raw)
+}
+
+// ScriptHash returns the blake2b hash of the script, the hash the indexer and the lock hash RPCs use.
+func ScriptHash(script *types.Script) (types.Hash, error) {
+	data, err := SerializeScript(script)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return Hash(data)
+}
+
+// Hash returns the blake2b-256 hash of data with the ckb-default-hash personalization.
+func Hash(data []byte) (types.Hash, error) {
+	hash, err := blake2b.Blake256(data)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return types.BytesToHash(hash), nil
+}
+
+func optionBytes(data []byte) []byte {
+	if data == nil {
+		return Option(nil)
+	}
+	return Bytes(data)
+}
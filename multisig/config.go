@@ -0,0 +1,148 @@
+package multisig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	ckbtransaction "github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+)
+
+const (
+	pubKeyHashLength = 20
+	sinceLength      = 8
+)
+
+// Config is a secp256k1-blake160-multisig-all configuration: Threshold signatures of the PubKeyHashes
+// unlock the cells, and the first RequireFirstN public keys must all sign.
+type Config struct {
+	RequireFirstN int
+	Threshold     int
+	PubKeyHashes  [][]byte
+}
+
+func NewConfig(requireFirstN int, threshold int, pubKeyHashes [][]byte) (*Config, error) {
+	if len(pubKeyHashes) == 0 || len(pubKeyHashes) > 255 {
+		return nil, errors.New("multisig needs from 1 to 255 public key hashes")
+	}
+	if threshold <= 0 || threshold > len(pubKeyHashes) {
+		return nil, fmt.Errorf("threshold %d must range from 1 to %d", threshold, len(pubKeyHashes))
+	}
+	if requireFirstN < 0 || requireFirstN > threshold {
+		return nil, fmt.Errorf("require first n %d must range from 0 to threshold %d", requireFirstN, threshold)
+	}
+	for i, hash := range pubKeyHashes {
+		if len(hash) != pubKeyHashLength {
+			return nil, fmt.Errorf("public key hash %d is %d bytes, want %d", i, len(hash), pubKeyHashLength)
+		}
+	}
+	return &Config{
+		RequireFirstN: requireFirstN,
+		Threshold:     threshold,
+		PubKeyHashes:  pubKeyHashes,
+	}, nil
+}
+
+// ParseConfig decodes a serialized multisig script.
+func ParseConfig(data []byte) (*Config, error) {
+	if len(data) < 4 || data[0] != 0 {
+		return nil, errors.New("invalid multisig script")
+	}
+	count := int(data[3])
+	if len(data) != 4+count*pubKeyHashLength {
+		return nil, fmt.Errorf("multisig script of %d public key hashes is %d bytes", count, len(data))
+	}
+	hashes := make([][]byte, count)
+	for i := range hashes {
+		hashes[i] = data[4+i*pubKeyHashLength : 4+(i+1)*pubKeyHashLength]
+	}
+	return NewConfig(int(data[1]), int(data[2]), hashes)
+}
+
+// Serialize returns the multisig script: the reserved byte, RequireFirstN, Threshold, the key count and the public key hashes.
+func (c *Config) Serialize() []byte {
+	data := []byte{0, byte(c.RequireFirstN), byte(c.Threshold), byte(len(c.PubKeyHashes))}
+	for _, hash := range c.PubKeyHashes {
+		data = append(data, hash...)
+	}
+	return data
+}
+
+// Hash160 returns the blake160 hash of the multisig script, the lock args.
+func (c *Config) Hash160() ([]byte, error) {
+	return blake2b.Blake160(c.Serialize())
+}
+
+// Script returns the multisig lock script.
+func (c *Config) Script() (*types.Script, error) {
+	args, err := c.Hash160()
+	if err != nil {
+		return nil, err
+	}
+	return &types.Script{
+		CodeHash: types.HexToHash(ckbtransaction.SECP256K1_BLAKE160_MULTISIG_ALL_TYPE_HASH),
+		HashType: types.HashTypeType,
+		Args:     args,
+	}, nil
+}
+
+// ScriptWithSince returns the multisig lock script which only unlocks inputs whose since is at least since.
+func (c *Config) ScriptWithSince(since uint64) (*types.Script, error) {
+	script, err := c.Script()
+	if err != nil {
+		return nil, err
+	}
+	script.Args = append(script.Args, molecule.Uint64(since)...)
+	return script, nil
+}
+
+// Address returns the short multisig address.
+func (c *Config) Address(mode address.Mode) (string, error) {
+	script, err := c.Script()
+	if err != nil {
+		return "", err
+	}
+	return address.Generate(mode, script)
+}
+
+// AddressWithSince returns the full address of the multisig lock which only unlocks inputs whose since is at least since.
+func (c *Config) AddressWithSince(mode address.Mode, since uint64) (string, error) {
+	script, err := c.ScriptWithSince(since)
+	if err != nil {
+		return "", err
+	}
+	return address.Generate(mode, script)
+}
+
+// Matches reports whether the lock script is a multisig lock of the configuration, with or without since.
+func (c *Config) Matches(lock *types.Script) bool {
+	if lock.CodeHash != types.HexToHash(ckbtransaction.SECP256K1_BLAKE160_MULTISIG_ALL_TYPE_HASH) || lock.HashType != types.HashTypeType {
+		return false
+	}
+	if len(lock.Args) != pubKeyHashLength && len(lock.Args) != pubKeyHashLength+sinceLength {
+		return false
+	}
+	args, err := c.Hash160()
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(lock.Args[:pubKeyHashLength], args)
+}
+
+func (c *Config) index(pubKeyHash []byte) int {
+	for i, hash := range c.PubKeyHashes {
+		if bytes.Equal(hash, pubKeyHash) {
+			return i
+		}
+	}
+	return -1
+}
+
+// LockPlaceholder returns the witness lock signed over: the multisig script followed by zero-filled signatures.
+func (c *Config) LockPlaceholder() []byte {
+	return append(c.Serialize(), make([]byte, c.Threshold*signatureLength)...)
+}
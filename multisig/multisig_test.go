@@ -0,0 +1,182 @@
+package multisig
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	ckbtransaction "github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+var testKeys = []string{
+	"d00c06bfd800d27397002dca6fb0993d5ba6399b4238b2f29ee9deb97593d2bc",
+	"63d86723e08f0f813a36ce6aa123bb2289d90680ae1e99d4de8cdb334553f24d",
+	"e79f3207ea4980b7fed79956d5934249ceac4751a4fae01a0f7c4a96884bc4e3",
+}
+
+// testConfig returns a 2 of 3 multisig configuration of testKeys whose first key must sign.
+func testConfig(t *testing.T) (*Config, []*secp256k1.Secp256k1Key) {
+	keys := make([]*secp256k1.Secp256k1Key, len(testKeys))
+	hashes := make([][]byte, len(testKeys))
+	for i, hex := range testKeys {
+		key, err := secp256k1.HexToKey(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash, err := blake2b.Blake160(key.PubKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i], hashes[i] = key, hash
+	}
+	config, err := NewConfig(1, 2, hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config, keys
+}
+
+func testTransaction(t *testing.T, config *Config) (*types.Transaction, []*transaction.ScriptGroup) {
+	lock, err := config.Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &types.Transaction{
+		CellDeps: []*types.CellDep{{
+			OutPoint: &types.OutPoint{TxHash: types.HexToHash("0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c"), Index: 1},
+			DepType:  types.DepTypeDepGroup,
+		}},
+		HeaderDeps: []types.Hash{},
+		Inputs: []*types.CellInput{{
+			PreviousOutput: &types.OutPoint{TxHash: types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541")},
+		}},
+		Outputs:     []*types.CellOutput{{Capacity: 6100000000, Lock: lock}},
+		OutputsData: [][]byte{{}},
+		Witnesses:   [][]byte{{}},
+	}
+	groups, err := transaction.GroupInputs([]*types.Script{lock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx, groups
+}
+
+func TestConfig(t *testing.T) {
+	config, _ := testConfig(t)
+	data := config.Serialize()
+	if !bytes.Equal(data[:4], []byte{0, 1, 2, 3}) || len(data) != 4+3*pubKeyHashLength {
+		t.Errorf("multisig script is %x", data)
+	}
+	parsed, err := ParseConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.Serialize(), data) {
+		t.Errorf("parsed multisig script is %x, want %x", parsed.Serialize(), data)
+	}
+	if len(config.LockPlaceholder()) != len(data)+2*signatureLength {
+		t.Errorf("lock placeholder is %d bytes", len(config.LockPlaceholder()))
+	}
+
+	script, err := config.ScriptWithSince(0x2000000000000010)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(script.Args) != pubKeyHashLength+sinceLength || !config.Matches(script) {
+		t.Errorf("multisig lock with since %x does not match", script.Args)
+	}
+	if _, err := NewConfig(2, 1, config.PubKeyHashes); err == nil {
+		t.Error("require first n above the threshold")
+	}
+}
+
+func TestSigner(t *testing.T) {
+	config, keys := testConfig(t)
+	tx, groups := testTransaction(t, config)
+
+	s, err := NewSigner(config, keys[1], keys[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := s.SignGroup(tx, groups[0])
+	if err != nil || ok {
+		t.Fatalf("SignGroup without the required first key returned %v, %v", ok, err)
+	}
+
+	s, err = NewSigner(config, keys[2], keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err = s.SignGroup(tx, groups[0])
+	if err != nil || !ok {
+		t.Fatalf("SignGroup returned %v, %v", ok, err)
+	}
+	// the upstream SDK signs the group independently with the keys in configuration order.
+	upstream, _ := testTransaction(t, config)
+	err = ckbtransaction.MultiSignTransaction(upstream, []int{0}, &types.WitnessArgs{}, config.Serialize(), keys[0], keys[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tx.Witnesses[0], upstream.Witnesses[0]) {
+		t.Errorf("witness is %x, upstream signs %x", tx.Witnesses[0], upstream.Witnesses[0])
+	}
+}
+
+func TestPartialTransaction(t *testing.T) {
+	config, keys := testConfig(t)
+	tx, groups := testTransaction(t, config)
+	first, err := NewPartialTransaction(tx, groups, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Sign(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if first.Complete() {
+		t.Error("partial transaction of one signature is complete")
+	}
+
+	// the second party signs the transaction received as JSON.
+	data, err := json.Marshal(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := &PartialTransaction{}
+	if err := json.Unmarshal(data, second); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Sign(keys[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Merge(second); err != nil {
+		t.Fatal(err)
+	}
+	if !first.Complete() {
+		t.Fatal("partial transaction of the threshold signatures is incomplete")
+	}
+	signed, err := first.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := testTransaction(t, config)
+	s, err := NewSigner(config, keys[0], keys[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SignGroup(expected, groups[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(signed.Witnesses[0], expected.Witnesses[0]) {
+		t.Errorf("finalized witness is %x, want %x", signed.Witnesses[0], expected.Witnesses[0])
+	}
+
+	second.Signatures[0][string(config.PubKeyHashes[1])] = second.Signatures[0][string(config.PubKeyHashes[2])]
+	if err := first.Merge(second); err == nil {
+		t.Error("merged a signature of another key")
+	}
+}
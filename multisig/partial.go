@@ -0,0 +1,288 @@
+package multisig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	ckbrpc "github.com/nervosnetwork/ckb-sdk-go/rpc"
+	ckbtransaction "github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/signer"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+// PartialTransaction collects the signatures of the multisig parties over a transaction.
+// It marshals to JSON so that it can be passed from one party to the next, and merged when parties sign in parallel.
+type PartialTransaction struct {
+	Transaction *types.Transaction
+	Config      *Config
+	Groups      []*transaction.ScriptGroup
+	// Signatures holds the signatures of each group, keyed by the signer public key hash.
+	Signatures []map[string][]byte
+}
+
+// NewPartialTransaction keeps the script groups locked by the multisig configuration, other groups are signed separately.
+func NewPartialTransaction(tx *types.Transaction, groups []*transaction.ScriptGroup, config *Config) (*PartialTransaction, error) {
+	p := &PartialTransaction{
+		Transaction: tx,
+		Config:      config,
+	}
+	for _, group := range groups {
+		if config.Matches(group.Script) {
+			p.Groups = append(p.Groups, group)
+			p.Signatures = append(p.Signatures, make(map[string][]byte))
+		}
+	}
+	if len(p.Groups) == 0 {
+		return nil, errors.New("no script group is locked by the multisig script")
+	}
+	return p, nil
+}
+
+// Sign adds the signatures of key to every group.
+func (p *PartialTransaction) Sign(key *secp256k1.Secp256k1Key) error {
+	hash, err := blake2b.Blake160(key.PubKey())
+	if err != nil {
+		return err
+	}
+	if p.Config.index(hash) < 0 {
+		return fmt.Errorf("public key hash %x is not in the multisig script", hash)
+	}
+	for i, group := range p.Groups {
+		message, _, err := signer.SighashAllMessage(p.Transaction, group, p.Config.LockPlaceholder())
+		if err != nil {
+			return err
+		}
+		signature, err := key.Sign(message)
+		if err != nil {
+			return err
+		}
+		p.Signatures[i][string(hash)] = signature
+	}
+	return nil
+}
+
+// Merge adds the signatures of other, which must be the same transaction and script groups. Every signature is
+// verified against the signer public key hash before it is added.
+func (p *PartialTransaction) Merge(other *PartialTransaction) error {
+	if !bytes.Equal(p.Config.Serialize(), other.Config.Serialize()) {
+		return errors.New("partial transactions have different multisig scripts")
+	}
+	hash, err := molecule.TransactionHash(p.Transaction)
+	if err != nil {
+		return err
+	}
+	otherHash, err := molecule.TransactionHash(other.Transaction)
+	if err != nil {
+		return err
+	}
+	if hash != otherHash {
+		return fmt.Errorf("partial transactions have different hashes %s and %s", hash.String(), otherHash.String())
+	}
+	if len(p.Groups) != len(other.Groups) {
+		return fmt.Errorf("partial transactions have %d and %d script groups", len(p.Groups), len(other.Groups))
+	}
+
+	for i, group := range p.Groups {
+		if !sameGroup(group, other.Groups[i]) {
+			return fmt.Errorf("partial transactions have different script group %d", i)
+		}
+		err := p.verify(group, other.Signatures[i])
+		if err != nil {
+			return err
+		}
+	}
+	for i := range p.Groups {
+		for key, signature := range other.Signatures[i] {
+			p.Signatures[i][key] = signature
+		}
+	}
+	return nil
+}
+
+// Verify checks every collected signature against the signer public key hash.
+func (p *PartialTransaction) Verify() error {
+	for i, group := range p.Groups {
+		err := p.verify(group, p.Signatures[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Complete reports whether every group has the required first signatures and enough signatures to reach the threshold.
+func (p *PartialTransaction) Complete() bool {
+	for _, signatures := range p.Signatures {
+		if p.signingIndices(signatures) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Finalize fills the group witnesses with the multisig script and the signatures in configuration order.
+func (p *PartialTransaction) Finalize() (*types.Transaction, error) {
+	for i, group := range p.Groups {
+		indices := p.signingIndices(p.Signatures[i])
+		if indices == nil {
+			return nil, fmt.Errorf("script group %d has %d of %d signatures", i, len(p.Signatures[i]), p.Config.Threshold)
+		}
+		if len(p.Transaction.Witnesses) < len(p.Transaction.Inputs) {
+			return nil, fmt.Errorf("transaction has %d inputs but %d witnesses", len(p.Transaction.Inputs), len(p.Transaction.Witnesses))
+		}
+		witnessArgs, err := molecule.DeserializeWitnessArgs(p.Transaction.Witnesses[group.InputIndices[0]])
+		if err != nil {
+			return nil, err
+		}
+		lock := p.Config.Serialize()
+		for _, index := range indices {
+			lock = append(lock, p.Signatures[i][string(p.Config.PubKeyHashes[index])]...)
+		}
+		witnessArgs.Lock = lock
+		p.Transaction.Witnesses[group.InputIndices[0]] = molecule.SerializeWitnessArgs(witnessArgs)
+	}
+	return p.Transaction, nil
+}
+
+func (p *PartialTransaction) verify(group *transaction.ScriptGroup, signatures map[string][]byte) error {
+	if len(signatures) == 0 {
+		return nil
+	}
+	message, _, err := signer.SighashAllMessage(p.Transaction, group, p.Config.LockPlaceholder())
+	if err != nil {
+		return err
+	}
+	for key, signature := range signatures {
+		if p.Config.index([]byte(key)) < 0 {
+			return fmt.Errorf("public key hash %x is not in the multisig script", key)
+		}
+		if len(signature) != signatureLength {
+			return fmt.Errorf("signature of %x is %d bytes, want %d", key, len(signature), signatureLength)
+		}
+		hash, err := signer.RecoverPubKeyHash(message, signature)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hash, []byte(key)) {
+			return fmt.Errorf("signature of %x is signed by %x", key, hash)
+		}
+	}
+	return nil
+}
+
+func (p *PartialTransaction) signingIndices(signatures map[string][]byte) []int {
+	return p.Config.signingIndices(func(index int) bool {
+		_, ok := signatures[string(p.Config.PubKeyHashes[index])]
+		return ok
+	})
+}
+
+func sameGroup(a *transaction.ScriptGroup, b *transaction.ScriptGroup) bool {
+	if !bytes.Equal(a.Script.Args, b.Script.Args) || len(a.InputIndices) != len(b.InputIndices) {
+		return false
+	}
+	for i := range a.InputIndices {
+		if a.InputIndices[i] != b.InputIndices[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type partialGroup struct {
+	Args         hexutil.Bytes            `json:"args"`
+	InputIndices []int                    `json:"input_indices"`
+	Signatures   map[string]hexutil.Bytes `json:"signatures"`
+}
+
+type partialTransaction struct {
+	Transaction    json.RawMessage `json:"transaction"`
+	MultisigScript hexutil.Bytes   `json:"multisig_script"`
+	Groups         []partialGroup  `json:"script_groups"`
+}
+
+// MarshalJSON encodes the transaction in the node RPC format, and keys the signatures by hex public key hash.
+func (p *PartialTransaction) MarshalJSON() ([]byte, error) {
+	tx, err := ckbrpc.TransactionString(p.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]partialGroup, len(p.Groups))
+	for i, group := range p.Groups {
+		signatures := make(map[string]hexutil.Bytes, len(p.Signatures[i]))
+		for key, signature := range p.Signatures[i] {
+			signatures[hexutil.Encode([]byte(key))] = signature
+		}
+		groups[i] = partialGroup{
+			Args:         group.Script.Args,
+			InputIndices: group.InputIndices,
+			Signatures:   signatures,
+		}
+	}
+	return json.Marshal(&partialTransaction{
+		Transaction:    json.RawMessage(tx),
+		MultisigScript: p.Config.Serialize(),
+		Groups:         groups,
+	})
+}
+
+// UnmarshalJSON decodes a partial transaction and verifies its signatures.
+func (p *PartialTransaction) UnmarshalJSON(data []byte) error {
+	var s partialTransaction
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+	tx, err := ckbrpc.TransactionFromString(string(s.Transaction))
+	if err != nil {
+		return err
+	}
+	config, err := ParseConfig(s.MultisigScript)
+	if err != nil {
+		return err
+	}
+
+	groups := make([]*transaction.ScriptGroup, len(s.Groups))
+	signatures := make([]map[string][]byte, len(s.Groups))
+	for i, group := range s.Groups {
+		groups[i] = &transaction.ScriptGroup{
+			Script: &types.Script{
+				CodeHash: types.HexToHash(ckbtransaction.SECP256K1_BLAKE160_MULTISIG_ALL_TYPE_HASH),
+				HashType: types.HashTypeType,
+				Args:     group.Args,
+			},
+			InputIndices: group.InputIndices,
+		}
+		if !config.Matches(groups[i].Script) || len(group.InputIndices) == 0 {
+			return fmt.Errorf("script group %d is not locked by the multisig script", i)
+		}
+		signatures[i] = make(map[string][]byte, len(group.Signatures))
+		for key, signature := range group.Signatures {
+			hash, err := hexutil.Decode(key)
+			if err != nil {
+				return err
+			}
+			signatures[i][string(hash)] = signature
+		}
+	}
+
+	partial := &PartialTransaction{
+		Transaction: tx,
+		Config:      config,
+		Groups:      groups,
+		Signatures:  signatures,
+	}
+	err = partial.Verify()
+	if err != nil {
+		return err
+	}
+	*p = *partial
+	return nil
+}
@@ -0,0 +1,117 @@
+package multisig
+
+import (
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/signer"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+const signatureLength = signer.SignatureLength
+
+var _ signer.Signer = (*Signer)(nil)
+
+// Signer signs the multisig script groups of its configuration when it holds enough of the keys to unlock them alone.
+// Use PartialTransaction to collect the signatures of several parties.
+type Signer struct {
+	config *Config
+	keys   map[int]*secp256k1.Secp256k1Key
+}
+
+func NewSigner(config *Config, keys ...*secp256k1.Secp256k1Key) (*Signer, error) {
+	s := &Signer{
+		config: config,
+		keys:   make(map[int]*secp256k1.Secp256k1Key, len(keys)),
+	}
+	for _, key := range keys {
+		err := s.AddKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// AddKey adds a private key, its public key hash must be one of the configuration.
+func (s *Signer) AddKey(key *secp256k1.Secp256k1Key) error {
+	index, err := s.config.keyIndex(key)
+	if err != nil {
+		return err
+	}
+	s.keys[index] = key
+	return nil
+}
+
+func (s *Signer) SignGroup(tx *types.Transaction, group *transaction.ScriptGroup) (bool, error) {
+	if !s.config.Matches(group.Script) {
+		return false, nil
+	}
+	message, witnessArgs, err := signer.SighashAllMessage(tx, group, s.config.LockPlaceholder())
+	if err != nil {
+		return false, err
+	}
+	lock, ok, err := s.SignMessage(message)
+	if err != nil || !ok {
+		return false, err
+	}
+	witnessArgs.Lock = lock
+	tx.Witnesses[group.InputIndices[0]] = molecule.SerializeWitnessArgs(witnessArgs)
+	return true, nil
+}
+
+// SignMessage returns the multisig witness lock of the message: the multisig script followed by the signatures. It
+// reports false when the signer does not hold enough of the keys, such as for locks which embed the multisig script.
+func (s *Signer) SignMessage(message []byte) ([]byte, bool, error) {
+	indices := s.config.signingIndices(func(index int) bool {
+		_, ok := s.keys[index]
+		return ok
+	})
+	if indices == nil {
+		return nil, false, nil
+	}
+	lock := s.config.Serialize()
+	for _, index := range indices {
+		signature, err := s.keys[index].Sign(message)
+		if err != nil {
+			return nil, false, err
+		}
+		lock = append(lock, signature...)
+	}
+	return lock, true, nil
+}
+
+func (c *Config) keyIndex(key *secp256k1.Secp256k1Key) (int, error) {
+	hash, err := blake2b.Blake160(key.PubKey())
+	if err != nil {
+		return 0, err
+	}
+	index := c.index(hash)
+	if index < 0 {
+		return 0, fmt.Errorf("public key hash %x is not in the multisig script", hash)
+	}
+	return index, nil
+}
+
+// signingIndices returns the indices of the keys whose signatures unlock a group, in configuration order: the
+// required first keys, then the others up to the threshold. It returns nil when a required key is missing or the
+// threshold is not reached.
+func (c *Config) signingIndices(has func(index int) bool) []int {
+	var indices []int
+	for i := 0; i < len(c.PubKeyHashes) && len(indices) < c.Threshold; i++ {
+		if !has(i) {
+			if i < c.RequireFirstN {
+				return nil
+			}
+			continue
+		}
+		indices = append(indices, i)
+	}
+	if len(indices) < c.Threshold {
+		return nil
+	}
+	return indices
+}
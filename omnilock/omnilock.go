@@ -0,0 +1,242 @@
+// Package omnilock generates Omnilock addresses and signs Omnilock cells, whose args authorize a secp256k1-blake160
+// key, an Ethereum key or a multisig script, optionally with the administrator, anyone-can-pay and time-lock modes.
+package omnilock
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/builder"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/multisig"
+	"github.com/shaojunda/ckb-rich-sdk-go/signer"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+)
+
+const (
+	// AuthSecp256k1 authorizes the key whose blake160 public key hash is the auth content.
+	AuthSecp256k1 = 0x00
+	// AuthEthereum authorizes the Ethereum key whose address is the auth content, signing with personal sign.
+	AuthEthereum = 0x01
+	// AuthMultisig authorizes the multisig script whose blake160 hash is the auth content.
+	AuthMultisig = 0x06
+
+	// FlagAdministrator enables the administrator mode, whose administrators are listed in the RC cell of the args type id.
+	FlagAdministrator = 0x01
+	// FlagACP enables the anyone-can-pay mode with the minimum payments of the args.
+	FlagACP = 0x02
+	// FlagTimeLock enables the time-lock mode, the cells are only spent with at least the since of the args.
+	FlagTimeLock = 0x04
+
+	authContentLength = 20
+	sinceLength       = 8
+)
+
+// Config is a deployment of the Omnilock.
+type Config struct {
+	CodeHash types.Hash
+	HashType types.ScriptHashType
+	CellDep  *types.CellDep
+}
+
+var (
+	MainnetOmnilock = configOf(systemscript.Mainnet.MustGet(systemscript.Omnilock))
+	TestnetOmnilock = configOf(systemscript.Testnet.MustGet(systemscript.Omnilock))
+)
+
+// NewConfig returns the Omnilock deployment of the registry, such as a devnet registry it is registered in.
+func NewConfig(registry *systemscript.Registry) (*Config, error) {
+	script, err := registry.Get(systemscript.Omnilock)
+	if err != nil {
+		return nil, err
+	}
+	return configOf(script), nil
+}
+
+func configOf(script *systemscript.Script) *Config {
+	return &Config{
+		CodeHash: script.CodeHash,
+		HashType: script.HashType,
+		CellDep:  script.CellDep,
+	}
+}
+
+// OmnilockConfig returns the Omnilock deployment of the network.
+func OmnilockConfig(mode address.Mode) (*Config, error) {
+	registry, err := systemscript.ForNetwork(mode)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfig(registry)
+}
+
+// Args are the args of an Omnilock: the auth flag and content, the mode flags and the args of the enabled modes.
+type Args struct {
+	AuthFlag    byte
+	AuthContent []byte
+	Flags       byte
+	// AdminListCell is the type id of the RC cell listing the administrators of FlagAdministrator args.
+	AdminListCell types.Hash
+	// MinimumCKB and MinimumUDT are the exponents of ten of the minimum payments of FlagACP args.
+	MinimumCKB uint8
+	MinimumUDT uint8
+	// Since is the minimum since of the inputs of FlagTimeLock args.
+	Since uint64
+}
+
+// Secp256k1Args returns the args authorizing the secp256k1-blake160 public key hash.
+func Secp256k1Args(pubKeyHash []byte) *Args {
+	return &Args{
+		AuthFlag:    AuthSecp256k1,
+		AuthContent: pubKeyHash,
+	}
+}
+
+// EthereumArgs returns the args authorizing the Ethereum address.
+func EthereumArgs(ethereumAddress []byte) *Args {
+	return &Args{
+		AuthFlag:    AuthEthereum,
+		AuthContent: ethereumAddress,
+	}
+}
+
+// MultisigArgs returns the args authorizing the multisig script.
+func MultisigArgs(config *multisig.Config) (*Args, error) {
+	hash, err := config.Hash160()
+	if err != nil {
+		return nil, err
+	}
+	return &Args{
+		AuthFlag:    AuthMultisig,
+		AuthContent: hash,
+	}, nil
+}
+
+// EthereumAddress returns the Ethereum address of the key: the last 20 bytes of the keccak256 hash of the public key.
+func EthereumAddress(key *secp256k1.Secp256k1Key) []byte {
+	return crypto.PubkeyToAddress(key.PrivateKey.PublicKey).Bytes()
+}
+
+func ParseArgs(args []byte) (*Args, error) {
+	if len(args) < 1+authContentLength+1 {
+		return nil, fmt.Errorf("Omnilock args are %d bytes", len(args))
+	}
+	a := &Args{
+		AuthFlag:    args[0],
+		AuthContent: args[1 : 1+authContentLength],
+		Flags:       args[1+authContentLength],
+	}
+	rest := args[1+authContentLength+1:]
+	if a.Flags&FlagAdministrator != 0 {
+		if len(rest) < types.HashLength {
+			return nil, fmt.Errorf("Omnilock args are %d bytes", len(args))
+		}
+		a.AdminListCell = types.BytesToHash(rest[:types.HashLength])
+		rest = rest[types.HashLength:]
+	}
+	if a.Flags&FlagACP != 0 {
+		if len(rest) < 2 {
+			return nil, fmt.Errorf("Omnilock args are %d bytes", len(args))
+		}
+		a.MinimumCKB = rest[0]
+		a.MinimumUDT = rest[1]
+		rest = rest[2:]
+	}
+	if a.Flags&FlagTimeLock != 0 {
+		if len(rest) < sinceLength {
+			return nil, fmt.Errorf("Omnilock args are %d bytes", len(args))
+		}
+		a.Since = binary.LittleEndian.Uint64(rest)
+		rest = rest[sinceLength:]
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("Omnilock args of flags %#x have %d extra bytes", a.Flags, len(rest))
+	}
+	return a, nil
+}
+
+// Serialize encodes the args, followed by the args of the enabled modes in flag order.
+func (a *Args) Serialize() []byte {
+	args := append([]byte{a.AuthFlag}, a.AuthContent...)
+	args = append(args, a.Flags)
+	if a.Flags&FlagAdministrator != 0 {
+		args = append(args, a.AdminListCell.Bytes()...)
+	}
+	if a.Flags&FlagACP != 0 {
+		args = append(args, a.MinimumCKB, a.MinimumUDT)
+	}
+	if a.Flags&FlagTimeLock != 0 {
+		args = append(args, molecule.Uint64(a.Since)...)
+	}
+	return args
+}
+
+// Script returns the Omnilock of the args.
+func (c *Config) Script(args *Args) (*types.Script, error) {
+	if len(args.AuthContent) != authContentLength {
+		return nil, fmt.Errorf("auth content is %d bytes, want %d", len(args.AuthContent), authContentLength)
+	}
+	return &types.Script{
+		CodeHash: c.CodeHash,
+		HashType: c.HashType,
+		Args:     args.Serialize(),
+	}, nil
+}
+
+// Address returns the full address of the Omnilock of the args.
+func (c *Config) Address(mode address.Mode, args *Args) (string, error) {
+	script, err := c.Script(args)
+	if err != nil {
+		return "", err
+	}
+	return address.Generate(mode, script)
+}
+
+// Matches reports whether the lock is an Omnilock of the deployment.
+func (c *Config) Matches(lock *types.Script) bool {
+	return lock != nil && lock.CodeHash == c.CodeHash && lock.HashType == c.HashType
+}
+
+// AddSender adds the Omnilock of the args as a sender of the transfer, with the lock placeholder of its auth and the
+// since of the time-lock mode. The multisig script is only needed by AuthMultisig args.
+func (c *Config) AddSender(t *builder.TransferBuilder, args *Args, config *multisig.Config) error {
+	lock, err := c.Script(args)
+	if err != nil {
+		return err
+	}
+	placeholder, err := WitnessLockPlaceholder(args, config)
+	if err != nil {
+		return err
+	}
+	var since uint64
+	if args.Flags&FlagTimeLock != 0 {
+		since = args.Since
+	}
+	t.AddSenderScript(lock, len(placeholder), since)
+	t.AddCellDep(c.CellDep)
+	return nil
+}
+
+// WitnessLock returns the OmniLockWitnessLock of the signature, without administrator identity or preimage.
+func WitnessLock(signature []byte) []byte {
+	return molecule.Table(molecule.Option(molecule.Bytes(signature)), molecule.Option(nil), molecule.Option(nil))
+}
+
+// WitnessLockPlaceholder returns the witness lock of the args with a zero-filled signature. The multisig script is
+// only needed by AuthMultisig args.
+func WitnessLockPlaceholder(args *Args, config *multisig.Config) ([]byte, error) {
+	switch args.AuthFlag {
+	case AuthSecp256k1, AuthEthereum:
+		return WitnessLock(make([]byte, signer.SignatureLength)), nil
+	case AuthMultisig:
+		if config == nil {
+			return nil, fmt.Errorf("multisig script of auth content %x is required", args.AuthContent)
+		}
+		return WitnessLock(config.LockPlaceholder()), nil
+	}
+	return nil, fmt.Errorf("unsupported Omnilock auth flag %#x", args.AuthFlag)
+}
@@ -0,0 +1,188 @@
+package omnilock
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/signer"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+// testKey is the private key of the EIP-155 example transaction, whose Ethereum address is testEthereumAddress.
+const (
+	testKey             = "4646464646464646464646464646464646464646464646464646464646464646"
+	testEthereumAddress = "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f"
+)
+
+func testSigner(t *testing.T) (*Signer, *secp256k1.Secp256k1Key) {
+	key, err := secp256k1.HexToKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSigner(MainnetOmnilock, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, key
+}
+
+func testTransaction(t *testing.T, args *Args) (*types.Transaction, *transaction.ScriptGroup) {
+	lock, err := MainnetOmnilock.Script(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &types.Transaction{
+		CellDeps:   []*types.CellDep{MainnetOmnilock.CellDep},
+		HeaderDeps: []types.Hash{},
+		Inputs: []*types.CellInput{{
+			PreviousOutput: &types.OutPoint{TxHash: types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541")},
+		}},
+		Outputs:     []*types.CellOutput{{Capacity: 6100000000, Lock: lock}},
+		OutputsData: [][]byte{{}},
+		Witnesses:   [][]byte{{}},
+	}
+	return tx, &transaction.ScriptGroup{Script: lock, InputIndices: []int{0}}
+}
+
+func TestEthereumAddress(t *testing.T) {
+	_, key := testSigner(t)
+	if got := hex.EncodeToString(EthereumAddress(key)); got != testEthereumAddress {
+		t.Errorf("Ethereum address is %s, want %s", got, testEthereumAddress)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	args := &Args{
+		AuthFlag:      AuthEthereum,
+		AuthContent:   bytes.Repeat([]byte{0x11}, authContentLength),
+		Flags:         FlagAdministrator | FlagACP | FlagTimeLock,
+		AdminListCell: types.HexToHash("0x2222222222222222222222222222222222222222222222222222222222222222"),
+		MinimumCKB:    3,
+		MinimumUDT:    4,
+		Since:         0x2000000000000010,
+	}
+	data := args.Serialize()
+	want := "01" + hex.EncodeToString(args.AuthContent) + "07" +
+		hex.EncodeToString(args.AdminListCell.Bytes()) + "0304" + "1000000000000020"
+	if hex.EncodeToString(data) != want {
+		t.Errorf("serialized args are %x, want %s", data, want)
+	}
+	parsed, err := ParseArgs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.Serialize(), data) || parsed.Since != args.Since || parsed.AdminListCell != args.AdminListCell {
+		t.Errorf("parsed args %+v, want %+v", parsed, args)
+	}
+
+	if _, err := ParseArgs(data[:len(data)-1]); err == nil {
+		t.Error("parsed args missing a since byte")
+	}
+	if _, err := ParseArgs(append(Secp256k1Args(args.AuthContent).Serialize(), 0)); err == nil {
+		t.Error("parsed args with extra bytes")
+	}
+	if _, err := MainnetOmnilock.Script(Secp256k1Args([]byte{1})); err == nil {
+		t.Error("script of a short auth content")
+	}
+}
+
+func TestWitnessLockPlaceholder(t *testing.T) {
+	// the OmniLockWitnessLock table of three fields holding the 65 bytes signature only.
+	placeholder, err := WitnessLockPlaceholder(Secp256k1Args(make([]byte, authContentLength)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "55000000100000005500000055000000" + "41000000" + hex.EncodeToString(make([]byte, signer.SignatureLength))
+	if hex.EncodeToString(placeholder) != want {
+		t.Errorf("placeholder is %x, want %s", placeholder, want)
+	}
+	multisigArgs := &Args{AuthFlag: AuthMultisig, AuthContent: make([]byte, authContentLength)}
+	if _, err := WitnessLockPlaceholder(multisigArgs, nil); err == nil {
+		t.Error("placeholder of multisig args without the multisig script")
+	}
+}
+
+func TestSignerSecp256k1(t *testing.T) {
+	s, key := testSigner(t)
+	hash, err := blake2b.Blake160(key.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := Secp256k1Args(hash)
+	tx, group := testTransaction(t, args)
+	placeholder, err := WitnessLockPlaceholder(args, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, _, err := signer.SighashAllMessage(tx, group, make([]byte, len(placeholder)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := s.SignGroup(tx, group)
+	if err != nil || !ok {
+		t.Fatalf("SignGroup returned %v, %v", ok, err)
+	}
+	signature := witnessSignature(t, tx.Witnesses[0])
+	recovered, err := signer.RecoverPubKeyHash(message, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(recovered, hash) {
+		t.Errorf("signature recovers public key hash %x, want %x", recovered, hash)
+	}
+	if len(tx.Witnesses[0]) != len(molecule.SerializeWitnessArgs(&types.WitnessArgs{Lock: placeholder})) {
+		t.Errorf("signed witness is %d bytes, the fee was estimated over a different size", len(tx.Witnesses[0]))
+	}
+
+	other, otherGroup := testTransaction(t, Secp256k1Args(make([]byte, authContentLength)))
+	ok, err = s.SignGroup(other, otherGroup)
+	if err != nil || ok {
+		t.Errorf("SignGroup of args without key returned %v, %v", ok, err)
+	}
+}
+
+func TestSignerEthereum(t *testing.T) {
+	s, key := testSigner(t)
+	args := EthereumArgs(EthereumAddress(key))
+	tx, group := testTransaction(t, args)
+	message, _, err := signer.SighashAllMessage(tx, group, make([]byte, len(WitnessLock(make([]byte, signer.SignatureLength)))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := s.SignGroup(tx, group)
+	if err != nil || !ok {
+		t.Fatalf("SignGroup returned %v, %v", ok, err)
+	}
+	// the Ethereum key signs the personal sign hash of the message.
+	pubKey, err := crypto.SigToPub(crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n32"), message), witnessSignature(t, tx.Witnesses[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(crypto.PubkeyToAddress(*pubKey).Bytes()); got != testEthereumAddress {
+		t.Errorf("signature recovers Ethereum address %s, want %s", got, testEthereumAddress)
+	}
+}
+
+// witnessSignature returns the signature of the OmniLockWitnessLock in the witness.
+func witnessSignature(t *testing.T, witness []byte) []byte {
+	witnessArgs, err := molecule.DeserializeWitnessArgs(witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := molecule.ParseTable(witnessArgs.Lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 3 || len(fields[0]) != 4+signer.SignatureLength {
+		t.Fatalf("witness lock is %x", witnessArgs.Lock)
+	}
+	return fields[0][4:]
+}
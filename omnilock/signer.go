@@ -0,0 +1,140 @@
+package omnilock
+
+import (
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/multisig"
+	"github.com/shaojunda/ckb-rich-sdk-go/signer"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+// ethereumMessagePrefix is the personal sign prefix of a 32 bytes message.
+const ethereumMessagePrefix = "\x19Ethereum Signed Message:\n32"
+
+var _ signer.Signer = (*Signer)(nil)
+
+// Signer signs the Omnilock script groups authorized by its secp256k1 keys, as secp256k1-blake160 or Ethereum
+// auth, and by its multisig scripts. The administrator identity is left out of the witness, the owner auth of the
+// args unlocks in every mode.
+type Signer struct {
+	config    *Config
+	keys      map[string]*secp256k1.Secp256k1Key
+	multisigs map[string]*multisigSigner
+}
+
+type multisigSigner struct {
+	config *multisig.Config
+	signer *multisig.Signer
+}
+
+func NewSigner(config *Config, keys ...*secp256k1.Secp256k1Key) (*Signer, error) {
+	s := &Signer{
+		config:    config,
+		keys:      make(map[string]*secp256k1.Secp256k1Key, 2*len(keys)),
+		multisigs: make(map[string]*multisigSigner),
+	}
+	for _, key := range keys {
+		err := s.AddKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// AddKey adds a private key for the secp256k1-blake160 and the Ethereum auth.
+func (s *Signer) AddKey(key *secp256k1.Secp256k1Key) error {
+	hash, err := blake2b.Blake160(key.PubKey())
+	if err != nil {
+		return err
+	}
+	s.keys[authKey(AuthSecp256k1, hash)] = key
+	s.keys[authKey(AuthEthereum, EthereumAddress(key))] = key
+	return nil
+}
+
+// AddMultisig adds a multisig script with the private keys signing it, which must be enough to unlock it alone.
+func (s *Signer) AddMultisig(config *multisig.Config, keys ...*secp256k1.Secp256k1Key) error {
+	ms, err := multisig.NewSigner(config, keys...)
+	if err != nil {
+		return err
+	}
+	hash, err := config.Hash160()
+	if err != nil {
+		return err
+	}
+	s.multisigs[authKey(AuthMultisig, hash)] = &multisigSigner{
+		config: config,
+		signer: ms,
+	}
+	return nil
+}
+
+func (s *Signer) SignGroup(tx *types.Transaction, group *transaction.ScriptGroup) (bool, error) {
+	if !s.config.Matches(group.Script) {
+		return false, nil
+	}
+	args, err := ParseArgs(group.Script.Args)
+	if err != nil {
+		return false, err
+	}
+	key := authKey(args.AuthFlag, args.AuthContent)
+
+	var signature []byte
+	var witnessArgs *types.WitnessArgs
+	switch args.AuthFlag {
+	case AuthSecp256k1, AuthEthereum:
+		k, ok := s.keys[key]
+		if !ok {
+			return false, nil
+		}
+		var message []byte
+		message, witnessArgs, err = s.message(tx, group, args, nil)
+		if err != nil {
+			return false, err
+		}
+		if args.AuthFlag == AuthEthereum {
+			message = crypto.Keccak256([]byte(ethereumMessagePrefix), message)
+		}
+		signature, err = k.Sign(message)
+		if err != nil {
+			return false, err
+		}
+	case AuthMultisig:
+		ms, ok := s.multisigs[key]
+		if !ok {
+			return false, nil
+		}
+		var message []byte
+		message, witnessArgs, err = s.message(tx, group, args, ms.config)
+		if err != nil {
+			return false, err
+		}
+		signature, ok, err = ms.signer.SignMessage(message)
+		if err != nil || !ok {
+			return false, err
+		}
+	default:
+		return false, nil
+	}
+
+	witnessArgs.Lock = WitnessLock(signature)
+	tx.Witnesses[group.InputIndices[0]] = molecule.SerializeWitnessArgs(witnessArgs)
+	return true, nil
+}
+
+// message returns the sighash-all message of the group, signed over a zero-filled witness lock of the signed size.
+func (s *Signer) message(tx *types.Transaction, group *transaction.ScriptGroup, args *Args, config *multisig.Config) ([]byte, *types.WitnessArgs, error) {
+	placeholder, err := WitnessLockPlaceholder(args, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signer.SighashAllMessage(tx, group, make([]byte, len(placeholder)))
+}
+
+func authKey(flag byte, content []byte) string {
+	return string(append([]byte{flag}, content...))
+}
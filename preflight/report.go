@@ -0,0 +1,76 @@
+package preflight
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Check names a pre-flight check.
+type Check string
+
+const (
+	CheckLiveInputs       Check = "live_inputs"
+	CheckDuplicateInputs  Check = "duplicate_inputs"
+	CheckCapacity         Check = "capacity"
+	CheckFee              Check = "fee"
+	CheckOccupiedCapacity Check = "occupied_capacity"
+	CheckCellDeps         Check = "cell_deps"
+	CheckWitnesses        Check = "witnesses"
+	CheckSince            Check = "since"
+	CheckDryRun           Check = "dry_run"
+)
+
+// Issue is a check the transaction fails. Index is the input, output or cell dep the issue is about, -1 when it is
+// about the whole transaction.
+type Issue struct {
+	Check   Check
+	Index   int
+	Message string
+}
+
+func (i *Issue) String() string {
+	if i.Index < 0 {
+		return fmt.Sprintf("%s: %s", i.Check, i.Message)
+	}
+	return fmt.Sprintf("%s[%d]: %s", i.Check, i.Index, i.Message)
+}
+
+// Report is the outcome of the pre-flight checks of a transaction. The capacities and the fee are only known when
+// every input is live.
+type Report struct {
+	Issues []*Issue
+	// InputCapacity includes the compensation of DAO withdrawals when the validator knows the DAO script.
+	InputCapacity  uint64
+	OutputCapacity uint64
+	Fee            uint64
+	// Size is the size of the transaction in a block, and FeeRate its fee rate in shannons per KB.
+	Size    uint64
+	FeeRate uint64
+	// Cycles are the cycles the transaction consumes, only set by a successful dry run.
+	Cycles uint64
+}
+
+// OK reports whether the transaction passed every check.
+func (r *Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Err returns an error listing the issues, nil when the transaction passed every check.
+func (r *Report) Err() error {
+	if r.OK() {
+		return nil
+	}
+	messages := make([]string, len(r.Issues))
+	for i, issue := range r.Issues {
+		messages[i] = issue.String()
+	}
+	return fmt.Errorf("transaction failed %d pre-flight checks: %s", len(r.Issues), strings.Join(messages, "; "))
+}
+
+func (r *Report) add(check Check, index int, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, &Issue{
+		Check:   check,
+		Index:   index,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
@@ -0,0 +1,303 @@
+// Package preflight checks transactions on the client before they are sent, reporting every check a transaction
+// fails at once instead of the first error the node verification returns.
+package preflight
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/builder"
+	"github.com/shaojunda/ckb-rich-sdk-go/dao"
+	"github.com/shaojunda/ckb-rich-sdk-go/epoch"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/since"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+const (
+	// DefaultMaxFeeRate is the fee rate in shannons per KB above which the fee is reported, usually a forgotten
+	// change output.
+	DefaultMaxFeeRate = 1000 * builder.DefaultFeeRate
+
+	statusLive = "live"
+)
+
+// Validator runs the pre-flight checks of transactions: the inputs and cell deps are live, no input is spent twice,
+// the outputs hold their occupied capacity, the inputs cover the outputs and a fee within the fee rate bounds, every
+// input has a witness and a well-formed since, and optionally the node dry runs the transaction.
+//
+// Validate signed transactions, the size and so the fee rate of unsigned ones lack their signatures.
+type Validator struct {
+	client rpc.Client
+	// MinFeeRate and MaxFeeRate bound the fee rate in shannons per KB, a zero MaxFeeRate leaves it unbounded.
+	MinFeeRate uint64
+	MaxFeeRate uint64
+	// DAO is the DAO script of the chain. When set, the input capacity of DAO withdrawals includes their
+	// compensation, otherwise their transactions fail the capacity check.
+	DAO *dao.Script
+	// DryRun runs the transaction on the node to check its scripts and report its cycles.
+	DryRun bool
+	// BatchSize is the number of cells looked up in one GetLiveCell batch call.
+	BatchSize int
+}
+
+func NewValidator(client rpc.Client) *Validator {
+	return &Validator{
+		client:     client,
+		MinFeeRate: builder.DefaultFeeRate,
+		MaxFeeRate: DefaultMaxFeeRate,
+		BatchSize:  rpc.AggregateBatchSize,
+	}
+}
+
+// Validate runs the checks and reports the issues of the transaction. The error is only set when the node could
+// not be queried.
+func (v *Validator) Validate(ctx context.Context, tx *types.Transaction) (*Report, error) {
+	report := &Report{}
+	checkDuplicateInputs(tx, report)
+	checkWitnesses(tx, report)
+	checkSince(tx, report)
+	checkOutputs(tx, report)
+
+	size, err := transaction.Size(tx)
+	if err != nil {
+		return nil, err
+	}
+	report.Size = size
+
+	inputs, err := v.liveInputs(ctx, tx, report)
+	if err != nil {
+		return nil, err
+	}
+	err = v.checkCellDeps(ctx, tx, report)
+	if err != nil {
+		return nil, err
+	}
+	if inputs != nil {
+		err = v.checkCapacity(ctx, tx, inputs, report)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if v.DryRun {
+		result, err := v.client.DryRunTransaction(ctx, tx)
+		if err != nil {
+			report.add(CheckDryRun, -1, "%v", err)
+		} else {
+			report.Cycles = result.Cycles
+		}
+	}
+	return report, nil
+}
+
+func checkDuplicateInputs(tx *types.Transaction, report *Report) {
+	seen := make(map[string]int)
+	for i, input := range tx.Inputs {
+		key := outPointKey(input.PreviousOutput)
+		if first, ok := seen[key]; ok {
+			report.add(CheckDuplicateInputs, i, "cell %s is also spent by input %d", key, first)
+			continue
+		}
+		seen[key] = i
+	}
+}
+
+func checkWitnesses(tx *types.Transaction, report *Report) {
+	if len(tx.Witnesses) < len(tx.Inputs) {
+		report.add(CheckWitnesses, -1, "transaction has %d witnesses for %d inputs", len(tx.Witnesses), len(tx.Inputs))
+	}
+}
+
+func checkSince(tx *types.Transaction, report *Report) {
+	for i, input := range tx.Inputs {
+		s, err := since.Parse(input.Since)
+		if err != nil {
+			report.add(CheckSince, i, "%v", err)
+			continue
+		}
+		if s.Metric == since.MetricEpoch && !epoch.WellFormed(s.Value) {
+			report.add(CheckSince, i, "since epoch %#x has an index beyond its length", s.Value)
+		}
+	}
+}
+
+func checkOutputs(tx *types.Transaction, report *Report) {
+	if len(tx.OutputsData) != len(tx.Outputs) {
+		report.add(CheckOccupiedCapacity, -1, "transaction has %d outputs but %d outputs data", len(tx.Outputs), len(tx.OutputsData))
+	}
+	for i, output := range tx.Outputs {
+		report.OutputCapacity += output.Capacity
+		if output.Lock == nil {
+			report.add(CheckOccupiedCapacity, i, "output has no lock")
+			continue
+		}
+		if i >= len(tx.OutputsData) {
+			continue
+		}
+		occupied := transaction.OccupiedCapacity(output, tx.OutputsData[i])
+		if output.Capacity < occupied {
+			report.add(CheckOccupiedCapacity, i, "output holds %d shannons but occupies %d", output.Capacity, occupied)
+		}
+	}
+}
+
+// liveInputs returns the cells of the inputs, nil when some of them are not live.
+func (v *Validator) liveInputs(ctx context.Context, tx *types.Transaction, report *Report) ([]*types.CellInfo, error) {
+	batch := make([]types.BatchLiveCellItem, len(tx.Inputs))
+	for i, input := range tx.Inputs {
+		batch[i].OutPoint = *input.PreviousOutput
+		// the data tells DAO withdrawals apart.
+		batch[i].WithData = v.DAO != nil
+	}
+	err := v.batchLiveCells(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]*types.CellInfo, len(batch))
+	for i, item := range batch {
+		if !isLive(item.Result) {
+			report.add(CheckLiveInputs, i, "cell %s is %s", outPointKey(&item.OutPoint), item.Result.Status)
+			cells = nil
+			continue
+		}
+		if cells != nil {
+			cells[i] = item.Result.Cell
+		}
+	}
+	return cells, nil
+}
+
+// checkCellDeps checks that the cell deps are live, and so are the cells dep groups list.
+func (v *Validator) checkCellDeps(ctx context.Context, tx *types.Transaction, report *Report) error {
+	batch := make([]types.BatchLiveCellItem, len(tx.CellDeps))
+	for i, dep := range tx.CellDeps {
+		batch[i].OutPoint = *dep.OutPoint
+		batch[i].WithData = dep.DepType == types.DepTypeDepGroup
+	}
+	err := v.batchLiveCells(ctx, batch)
+	if err != nil {
+		return err
+	}
+	var members []types.BatchLiveCellItem
+	var groups []int
+	for i, item := range batch {
+		if !isLive(item.Result) {
+			report.add(CheckCellDeps, i, "cell %s is %s", outPointKey(&item.OutPoint), item.Result.Status)
+			continue
+		}
+		if !item.WithData {
+			continue
+		}
+		if item.Result.Cell.Data == nil {
+			report.add(CheckCellDeps, i, "dep group cell %s has no data", outPointKey(&item.OutPoint))
+			continue
+		}
+		outPoints, err := molecule.DeserializeOutPointVec(item.Result.Cell.Data.Content)
+		if err != nil {
+			report.add(CheckCellDeps, i, "dep group cell %s: %v", outPointKey(&item.OutPoint), err)
+			continue
+		}
+		for _, outPoint := range outPoints {
+			members = append(members, types.BatchLiveCellItem{OutPoint: *outPoint})
+			groups = append(groups, i)
+		}
+	}
+	err = v.batchLiveCells(ctx, members)
+	if err != nil {
+		return err
+	}
+	for i, item := range members {
+		if !isLive(item.Result) {
+			report.add(CheckCellDeps, groups[i], "cell %s of the dep group is %s", outPointKey(&item.OutPoint), item.Result.Status)
+		}
+	}
+	return nil
+}
+
+// checkCapacity checks that the live inputs cover the outputs and a fee within the fee rate bounds.
+func (v *Validator) checkCapacity(ctx context.Context, tx *types.Transaction, inputs []*types.CellInfo, report *Report) error {
+	for i, cell := range inputs {
+		capacity := cell.Output.Capacity
+		if v.DAO != nil && cell.Data != nil && v.DAO.Matches(cell.Output, cell.Data.Content) && binary.LittleEndian.Uint64(cell.Data.Content) != 0 {
+			var err error
+			capacity, err = v.withdraw(ctx, tx.Inputs[i].PreviousOutput, cell)
+			if err != nil {
+				return err
+			}
+		}
+		report.InputCapacity += capacity
+	}
+	if report.InputCapacity < report.OutputCapacity {
+		report.add(CheckCapacity, -1, "outputs hold %d shannons but inputs only %d", report.OutputCapacity, report.InputCapacity)
+		return nil
+	}
+
+	report.Fee = report.InputCapacity - report.OutputCapacity
+	report.FeeRate = report.Fee * 1000 / report.Size
+	if minimum := transaction.Fee(report.Size, v.MinFeeRate); report.Fee < minimum {
+		report.add(CheckFee, -1, "fee %d is below %d at the minimum fee rate %d shannons per KB", report.Fee, minimum, v.MinFeeRate)
+	}
+	if v.MaxFeeRate > 0 {
+		if maximum := transaction.Fee(report.Size, v.MaxFeeRate); report.Fee > maximum {
+			report.add(CheckFee, -1, "fee %d exceeds %d at the maximum fee rate %d shannons per KB", report.Fee, maximum, v.MaxFeeRate)
+		}
+	}
+	return nil
+}
+
+// withdraw returns the capacity a withdrawing DAO cell unlocks: its deposit block number is its data, its withdraw
+// block the block of the transaction which created it.
+func (v *Validator) withdraw(ctx context.Context, outPoint *types.OutPoint, cell *types.CellInfo) (uint64, error) {
+	depositHeader, err := v.client.GetHeaderByNumber(ctx, binary.LittleEndian.Uint64(cell.Data.Content))
+	if err != nil {
+		return 0, err
+	}
+	withdrawTx, err := v.client.GetTransaction(ctx, outPoint.TxHash)
+	if err != nil {
+		return 0, err
+	}
+	if withdrawTx.TxStatus.BlockHash == nil {
+		return 0, fmt.Errorf("DAO cell %s is not committed", outPointKey(outPoint))
+	}
+	withdrawHeader, err := v.client.GetHeader(ctx, *withdrawTx.TxStatus.BlockHash)
+	if err != nil {
+		return 0, err
+	}
+	return dao.MaximumWithdraw(cell.Output, depositHeader, withdrawHeader)
+}
+
+// batchLiveCells looks the cells up with one GetLiveCell batch call per BatchSize cells.
+func (v *Validator) batchLiveCells(ctx context.Context, batch []types.BatchLiveCellItem) error {
+	size := v.BatchSize
+	if size <= 0 {
+		size = rpc.AggregateBatchSize
+	}
+	for start := 0; start < len(batch); start += size {
+		end := start + size
+		if end > len(batch) {
+			end = len(batch)
+		}
+		err := v.client.BatchLiveCells(ctx, batch[start:end])
+		if err != nil {
+			return err
+		}
+		for i, item := range batch[start:end] {
+			if item.Error != nil {
+				return fmt.Errorf("get live cell %s error: %v", outPointKey(&batch[start+i].OutPoint), item.Error)
+			}
+		}
+	}
+	return nil
+}
+
+func isLive(cell *types.CellWithStatus) bool {
+	return cell.Status == statusLive && cell.Cell != nil
+}
+
+func outPointKey(outPoint *types.OutPoint) string {
+	return fmt.Sprintf("%s#%d", outPoint.TxHash.String(), outPoint.Index)
+}
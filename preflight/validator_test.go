@@ -0,0 +1,176 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+var (
+	inputTx   = types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541")
+	depTx     = types.HexToHash("0xe2fb199810d49a4d8beec56718ba2593b665db9d52299a0f9e6e75416d73ff5c")
+	depGroup  = types.HexToHash("0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c")
+	deadInput = types.HexToHash("0x4bb9b6b2d37ac4fe8fd2be4ac3bf12e2a4d52baf9f0dcfce9da6bc4a7e6ab3b6")
+)
+
+// chainClient serves live cells of 200 CKB at inputTx, the code cells at depTx, the dep group listing depTx#1 and
+// depTx#3 and a dry run, leaving the other methods unimplemented.
+type chainClient struct {
+	rpc.Client
+	dryRunErr error
+}
+
+func (c *chainClient) BatchLiveCells(ctx context.Context, batch []types.BatchLiveCellItem) error {
+	for i := range batch {
+		item := &batch[i]
+		switch {
+		case item.OutPoint.TxHash == inputTx:
+			item.Result = live(&types.CellOutput{Capacity: 200 * 100000000, Lock: secpLock()}, nil)
+		case item.OutPoint.TxHash == depTx:
+			item.Result = live(&types.CellOutput{Lock: secpLock()}, []byte("code"))
+		case item.OutPoint.TxHash == depGroup && item.OutPoint.Index == 0:
+			data := append(molecule.Uint32(2), molecule.SerializeOutPoint(&types.OutPoint{TxHash: depTx, Index: 1})...)
+			data = append(data, molecule.SerializeOutPoint(&types.OutPoint{TxHash: depTx, Index: 3})...)
+			item.Result = live(&types.CellOutput{Lock: secpLock()}, data)
+		default:
+			item.Result = &types.CellWithStatus{Status: "unknown"}
+		}
+	}
+	return nil
+}
+
+func (c *chainClient) DryRunTransaction(ctx context.Context, transaction *types.Transaction) (*types.DryRunTransactionResult, error) {
+	if c.dryRunErr != nil {
+		return nil, c.dryRunErr
+	}
+	return &types.DryRunTransactionResult{Cycles: 1600000}, nil
+}
+
+func live(output *types.CellOutput, data []byte) *types.CellWithStatus {
+	return &types.CellWithStatus{
+		Cell:   &types.CellInfo{Output: output, Data: &types.CellData{Content: data}},
+		Status: statusLive,
+	}
+}
+
+func secpLock() *types.Script {
+	return &types.Script{
+		CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
+		HashType: types.HashTypeType,
+		Args:     common.FromHex("0xedcda9513fa030ce4308e29245a22c022d0443bb"),
+	}
+}
+
+// signedTransfer returns a signed transfer of a 200 CKB cell of 464 bytes, paying the 464 shannons minimum fee.
+func signedTransfer() *types.Transaction {
+	return &types.Transaction{
+		CellDeps:    []*types.CellDep{{OutPoint: &types.OutPoint{TxHash: depGroup}, DepType: types.DepTypeDepGroup}},
+		HeaderDeps:  []types.Hash{},
+		Inputs:      []*types.CellInput{{PreviousOutput: &types.OutPoint{TxHash: inputTx}}},
+		Outputs:     []*types.CellOutput{{Capacity: 6100000000, Lock: secpLock()}, {Capacity: 13899999536, Lock: secpLock()}},
+		OutputsData: [][]byte{{}, {}},
+		Witnesses:   [][]byte{molecule.SerializeWitnessArgs(&types.WitnessArgs{Lock: make([]byte, 65)})},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(tx *types.Transaction)
+		issues []Check
+	}{
+		{"valid", func(tx *types.Transaction) {}, nil},
+		{"duplicate inputs", func(tx *types.Transaction) {
+			tx.Inputs = append(tx.Inputs, tx.Inputs[0])
+			tx.Witnesses = append(tx.Witnesses, []byte{})
+		}, []Check{CheckDuplicateInputs, CheckFee}},
+		{"missing witness", func(tx *types.Transaction) {
+			tx.Witnesses = nil
+		}, []Check{CheckWitnesses}},
+		// a zero length epoch since counts whole epochs, as the cheque withdraw since does.
+		{"whole epochs since", func(tx *types.Transaction) {
+			tx.Inputs[0].Since = 0xa000000000000006
+		}, nil},
+		{"zero length epoch since with index", func(tx *types.Transaction) {
+			tx.Inputs[0].Since = 0x2000000001000006
+		}, []Check{CheckSince}},
+		{"epoch since index at length", func(tx *types.Transaction) {
+			tx.Inputs[0].Since = 0x20000a000a000006
+		}, []Check{CheckSince}},
+		{"reserved since bits", func(tx *types.Transaction) {
+			tx.Inputs[0].Since = 0x0100000000000000
+		}, []Check{CheckSince}},
+		{"missing outputs data", func(tx *types.Transaction) {
+			tx.OutputsData = tx.OutputsData[:1]
+		}, []Check{CheckOccupiedCapacity}},
+		{"output below occupied capacity", func(tx *types.Transaction) {
+			tx.Outputs[0].Capacity = 6099999999
+			tx.Outputs[1].Capacity++
+		}, []Check{CheckOccupiedCapacity}},
+		{"outputs above inputs", func(tx *types.Transaction) {
+			tx.Outputs[1].Capacity += 465
+		}, []Check{CheckCapacity}},
+		{"fee below minimum", func(tx *types.Transaction) {
+			tx.Outputs[1].Capacity++
+		}, []Check{CheckFee}},
+		{"fee above maximum", func(tx *types.Transaction) {
+			tx.Outputs[1].Capacity = 13800000000
+		}, []Check{CheckFee}},
+		{"dead input", func(tx *types.Transaction) {
+			tx.Inputs[0].PreviousOutput.TxHash = deadInput
+		}, []Check{CheckLiveInputs}},
+		{"dead cell dep", func(tx *types.Transaction) {
+			tx.CellDeps[0] = &types.CellDep{OutPoint: &types.OutPoint{TxHash: deadInput}, DepType: types.DepTypeCode}
+		}, []Check{CheckCellDeps}},
+		{"dead dep group", func(tx *types.Transaction) {
+			tx.CellDeps[0].OutPoint.Index = 1
+		}, []Check{CheckCellDeps}},
+	}
+	for _, tt := range tests {
+		tx := signedTransfer()
+		tt.modify(tx)
+		report, err := NewValidator(&chainClient{}).Validate(context.Background(), tx)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(report.Issues) != len(tt.issues) {
+			t.Errorf("%s: got issues %v, want %v", tt.name, report.Err(), tt.issues)
+			continue
+		}
+		for i, issue := range report.Issues {
+			if issue.Check != tt.issues[i] {
+				t.Errorf("%s: issue %d is %s, want %s", tt.name, i, issue, tt.issues[i])
+			}
+		}
+	}
+}
+
+func TestValidateReport(t *testing.T) {
+	v := NewValidator(&chainClient{})
+	v.DryRun = true
+	report, err := v.Validate(context.Background(), signedTransfer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Err() != nil {
+		t.Fatalf("valid transfer failed %v", report.Err())
+	}
+	if report.Size != 464 || report.Fee != 464 || report.FeeRate != 1000 || report.InputCapacity != 200*100000000 || report.Cycles != 1600000 {
+		t.Errorf("report is %+v", report)
+	}
+
+	v = NewValidator(&chainClient{dryRunErr: errors.New("script error")})
+	v.DryRun = true
+	report, err = v.Validate(context.Background(), signedTransfer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Check != CheckDryRun {
+		t.Errorf("failed dry run reported %v", report.Err())
+	}
+}
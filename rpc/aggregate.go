@@ -0,0 +1,279 @@
+package rpc
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+)
+
+const (
+	// AggregateBatchSize is the number of search keys sent to the indexer in one batch call.
+	AggregateBatchSize = 100
+	// DefaultConcurrency is the number of batch calls in flight when no concurrency is given.
+	DefaultConcurrency = 4
+)
+
+type AggregatedCapacity struct {
+	// Capacities holds the capacity of every search key, in the same order as the search keys.
+	Capacities []*indexer.Capacity `json:"capacities"`
+	Capacity   uint64              `json:"capacity"`
+	// BlockNumber is the lowest block number seen across all results,
+	// every capacity includes at least the blocks up to it.
+	BlockNumber uint64 `json:"block_number"`
+}
+
+func (cli *client) GetCellsCapacities(ctx context.Context, searchKeys []*indexer.SearchKey, concurrency int) (*AggregatedCapacity, error) {
+	capacities := make([]*indexer.Capacity, len(searchKeys))
+	err := runChunks(ctx, len(searchKeys), AggregateBatchSize, concurrency, func(ctx context.Context, start int, end int) error {
+		batch := make([]indexer.BatchGetCellsCapacityItem, end-start)
+		for i := start; i < end; i++ {
+			batch[i-start].SearchKey = searchKeys[i]
+		}
+		err := cli.indexer.BatchGetCellsCapacity(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for i, item := range batch {
+			if item.Error != nil {
+				return fmt.Errorf("get cells capacity of search key %d error: %v", start+i, item.Error)
+			}
+			capacities[start+i] = item.Result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AggregatedCapacity{
+		Capacities: capacities,
+	}
+	for i, capacity := range capacities {
+		result.Capacity += capacity.Capacity
+		if i == 0 || capacity.BlockNumber < result.BlockNumber {
+			result.BlockNumber = capacity.BlockNumber
+		}
+	}
+	return result, nil
+}
+
+// CellStream merges the live cells of several search keys into a single stream
+// ordered by block number, transaction index and output index.
+type CellStream struct {
+	client   Client
+	order    indexer.SearchOrder
+	pageSize uint64
+	sources  cellSources
+	last     *indexer.LiveCell
+	// BlockNumber is the lowest indexer tip seen while fetching pages.
+	BlockNumber uint64
+}
+
+func (cli *client) GetMergedCells(ctx context.Context, searchKeys []*indexer.SearchKey, order indexer.SearchOrder, pageSize uint64, concurrency int) (*CellStream, error) {
+	if pageSize == 0 {
+		return nil, errors.New("page size is zero")
+	}
+	stream := &CellStream{
+		client:   cli,
+		order:    order,
+		pageSize: pageSize,
+	}
+	sources := make([]*cellSource, len(searchKeys))
+	tips := make([]uint64, 0, len(searchKeys)/AggregateBatchSize+1)
+	var mu sync.Mutex
+	err := runChunks(ctx, len(searchKeys), AggregateBatchSize, concurrency, func(ctx context.Context, start int, end int) error {
+		batch := indexer.NewBatchRequest()
+		tip := batch.AddGetTip()
+		items := make([]*indexer.BatchGetCellsItem, end-start)
+		for i := start; i < end; i++ {
+			items[i-start] = batch.AddGetCells(searchKeys[i], order, pageSize, "")
+		}
+		err := cli.indexer.BatchCall(ctx, batch)
+		if err != nil {
+			return err
+		}
+		if tip.Error != nil {
+			return tip.Error
+		}
+		for i, item := range items {
+			if item.Error != nil {
+				return fmt.Errorf("get cells of search key %d error: %v", start+i, item.Error)
+			}
+			sources[start+i] = newCellSource(searchKeys[start+i], item.Result, pageSize)
+		}
+		mu.Lock()
+		tips = append(tips, tip.Result.BlockNumber)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tip := range tips {
+		stream.observeTip(tip)
+	}
+	stream.sources.order = order
+	for _, source := range sources {
+		if len(source.cells) > 0 {
+			stream.sources.items = append(stream.sources.items, source)
+		}
+	}
+	heap.Init(&stream.sources)
+	return stream, nil
+}
+
+// Next returns the next live cell of the stream, or nil when all search keys are exhausted.
+// A cell matched by several search keys is returned only once.
+func (s *CellStream) Next(ctx context.Context) (*indexer.LiveCell, error) {
+	for s.sources.Len() > 0 {
+		source := s.sources.items[0]
+		// the next page is fetched before the last cell is taken, a failed fetch leaves the stream unchanged.
+		if len(source.cells) == 1 && !source.exhausted {
+			err := s.fetch(ctx, source)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cell := source.cells[0]
+		source.cells = source.cells[1:]
+		if len(source.cells) == 0 {
+			heap.Pop(&s.sources)
+		} else {
+			heap.Fix(&s.sources, 0)
+		}
+
+		if s.last != nil && sameOutPoint(s.last, cell) {
+			continue
+		}
+		s.last = cell
+		return cell, nil
+	}
+	return nil, nil
+}
+
+func (s *CellStream) fetch(ctx context.Context, source *cellSource) error {
+	batch := indexer.NewBatchRequest()
+	tip := batch.AddGetTip()
+	item := batch.AddGetCells(source.searchKey, s.order, s.pageSize, source.cursor)
+	err := s.client.BatchIndexer(ctx, batch)
+	if err != nil {
+		return err
+	}
+	if tip.Error != nil {
+		return tip.Error
+	}
+	if item.Error != nil {
+		return item.Error
+	}
+	s.observeTip(tip.Result.BlockNumber)
+	source.next(item.Result, s.pageSize)
+	return nil
+}
+
+func (s *CellStream) observeTip(blockNumber uint64) {
+	if s.BlockNumber == 0 || blockNumber < s.BlockNumber {
+		s.BlockNumber = blockNumber
+	}
+}
+
+type cellSource struct {
+	searchKey *indexer.SearchKey
+	cursor    string
+	cells     []*indexer.LiveCell
+	exhausted bool
+}
+
+func newCellSource(searchKey *indexer.SearchKey, cells *indexer.LiveCells, pageSize uint64) *cellSource {
+	source := &cellSource{
+		searchKey: searchKey,
+	}
+	source.next(cells, pageSize)
+	return source
+}
+
+func (s *cellSource) next(cells *indexer.LiveCells, pageSize uint64) {
+	s.cursor = cells.LastCursor
+	s.cells = append(s.cells, cells.Objects...)
+	s.exhausted = uint64(len(cells.Objects)) < pageSize
+}
+
+type cellSources struct {
+	order indexer.SearchOrder
+	items []*cellSource
+}
+
+func (h cellSources) Len() int { return len(h.items) }
+
+func (h cellSources) Less(i, j int) bool {
+	if h.order == indexer.SearchOrderDesc {
+		return indexer.CompareLiveCells(h.items[i].cells[0], h.items[j].cells[0]) > 0
+	}
+	return indexer.CompareLiveCells(h.items[i].cells[0], h.items[j].cells[0]) < 0
+}
+
+func (h cellSources) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *cellSources) Push(x interface{}) { h.items = append(h.items, x.(*cellSource)) }
+
+func (h *cellSources) Pop() interface{} {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items = h.items[:n-1]
+	return item
+}
+
+func sameOutPoint(a *indexer.LiveCell, b *indexer.LiveCell) bool {
+	return a.OutPoint.TxHash == b.OutPoint.TxHash && a.OutPoint.Index == b.OutPoint.Index
+}
+
+// runChunks splits total items into chunks and calls fn for each chunk with at most concurrency calls in flight.
+// It returns the first error and cancels the remaining chunks.
+func runChunks(ctx context.Context, total int, chunkSize int, concurrency int, fn func(ctx context.Context, start int, end int) error) error {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	for start := 0; start < total; start += chunkSize {
+		end := start + chunkSize
+		if end > total {
+			end = total
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(start int, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, start, end); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
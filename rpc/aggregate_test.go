@@ -0,0 +1,375 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+)
+
+var lockCode = types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8")
+
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// indexerServer is a JSON-RPC indexer of a list of live cells, whose cursors are positions in the matching cells.
+// It counts the HTTP requests it receives and calls onGetCells before answering every get_cells request, and fails
+// the get_cells requests of pages after the first one while failPages is set.
+type indexerServer struct {
+	mu         sync.Mutex
+	requests   int
+	cells      []*indexer.LiveCell
+	tip        uint64
+	fork       bool
+	failPages  bool
+	onGetCells func(s *indexerServer)
+}
+
+// blockHash returns the hash of the block at number on the current chain, which changes when the chain forks.
+func (s *indexerServer) blockHash(number uint64) types.Hash {
+	fork := byte(0)
+	if s.fork {
+		fork = 1
+	}
+	return types.BytesToHash([]byte{fork, byte(number >> 8), byte(number)})
+}
+
+func (s *indexerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		body = append(append([]byte("["), body...), ']')
+	}
+	var requests []request
+	if err := json.Unmarshal(body, &requests); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	responses := make([]response, len(requests))
+	for i, req := range requests {
+		responses[i] = response{Version: "2.0", ID: req.ID}
+		result, err := s.answer(req)
+		if err != nil {
+			responses[i].Error = &responseError{Code: -32000, Message: err.Error()}
+		} else {
+			responses[i].Result = result
+		}
+	}
+	if len(responses) == 1 {
+		json.NewEncoder(w).Encode(responses[0])
+		return
+	}
+	json.NewEncoder(w).Encode(responses)
+}
+
+func (s *indexerServer) answer(req request) (interface{}, error) {
+	switch req.Method {
+	case "get_tip":
+		return map[string]string{"block_hash": s.blockHash(s.tip).String(), "block_number": hexutil.EncodeUint64(s.tip)}, nil
+	case "get_cells":
+		if s.onGetCells != nil {
+			s.onGetCells(s)
+		}
+		var order indexer.SearchOrder
+		var limit hexutil.Uint64
+		if err := json.Unmarshal(req.Params[1], &order); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(req.Params[2], &limit); err != nil {
+			return nil, err
+		}
+		start := 0
+		if len(req.Params) == 4 {
+			if s.failPages {
+				return nil, errors.New("page unavailable")
+			}
+			var cursor string
+			if err := json.Unmarshal(req.Params[3], &cursor); err != nil {
+				return nil, err
+			}
+			position, err := strconv.Atoi(cursor)
+			if err != nil {
+				return nil, err
+			}
+			start = position
+		}
+		cells, err := s.matching(req.Params[0])
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(cells, func(i, j int) bool {
+			if order == indexer.SearchOrderDesc {
+				return indexer.CompareLiveCells(cells[i], cells[j]) > 0
+			}
+			return indexer.CompareLiveCells(cells[i], cells[j]) < 0
+		})
+		end := start + int(limit)
+		if end > len(cells) {
+			end = len(cells)
+		}
+		objects := make([]interface{}, 0, end-start)
+		for _, cell := range cells[start:end] {
+			objects = append(objects, map[string]interface{}{
+				"block_number": hexutil.EncodeUint64(cell.BlockNumber),
+				"out_point":    map[string]string{"tx_hash": cell.OutPoint.TxHash.String(), "index": hexutil.EncodeUint64(uint64(cell.OutPoint.Index))},
+				"output": map[string]interface{}{
+					"capacity": hexutil.EncodeUint64(cell.Output.Capacity),
+					"lock":     map[string]string{"code_hash": cell.Output.Lock.CodeHash.String(), "hash_type": string(cell.Output.Lock.HashType), "args": hexutil.Encode(cell.Output.Lock.Args)},
+				},
+				"output_data": "0x",
+				"tx_index":    hexutil.EncodeUint64(uint64(cell.TxIndex)),
+			})
+		}
+		return map[string]interface{}{"last_cursor": strconv.Itoa(end), "objects": objects}, nil
+	case "get_cells_capacity":
+		cells, err := s.matching(req.Params[0])
+		if err != nil {
+			return nil, err
+		}
+		var capacity uint64
+		for _, cell := range cells {
+			capacity += cell.Output.Capacity
+		}
+		return map[string]string{
+			"capacity":     hexutil.EncodeUint64(capacity),
+			"block_hash":   s.blockHash(s.tip).String(),
+			"block_number": hexutil.EncodeUint64(s.tip),
+		}, nil
+	}
+	return nil, fmt.Errorf("method %s not found", req.Method)
+}
+
+// matching returns the cells found by the encoded search key, failing for the args 0xff.
+func (s *indexerServer) matching(param json.RawMessage) ([]*indexer.LiveCell, error) {
+	var key struct {
+		Script struct {
+			CodeHash types.Hash           `json:"code_hash"`
+			HashType types.ScriptHashType `json:"hash_type"`
+			Args     hexutil.Bytes        `json:"args"`
+		} `json:"script"`
+		ScriptType indexer.ScriptType `json:"script_type"`
+	}
+	if err := json.Unmarshal(param, &key); err != nil {
+		return nil, err
+	}
+	if bytes.Equal(key.Script.Args, []byte{0xff}) {
+		return nil, errors.New("search key rejected")
+	}
+	searchKey := &indexer.SearchKey{
+		Script:     &types.Script{CodeHash: key.Script.CodeHash, HashType: key.Script.HashType, Args: key.Script.Args},
+		ScriptType: key.ScriptType,
+	}
+	var cells []*indexer.LiveCell
+	for _, cell := range s.cells {
+		if searchKey.Match(cell.Output) {
+			cells = append(cells, cell)
+		}
+	}
+	return cells, nil
+}
+
+// ckbClient answers the block hashes of the indexer server chain, leaving the other methods unimplemented.
+type ckbClient struct {
+	rpc.Client
+	server *indexerServer
+}
+
+func (c *ckbClient) GetBlockHash(ctx context.Context, number uint64) (*types.Hash, error) {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	hash := c.server.blockHash(number)
+	return &hash, nil
+}
+
+func testClient(t *testing.T, server *indexerServer) Client {
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	c, err := ethrpc.DialHTTP(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &client{ckb: &ckbClient{server: server}, indexer: indexer.NewClient(c)}
+}
+
+func searchKey(args ...byte) *indexer.SearchKey {
+	return &indexer.SearchKey{
+		Script:     &types.Script{CodeHash: lockCode, HashType: types.HashTypeType, Args: args},
+		ScriptType: indexer.ScriptTypeLock,
+	}
+}
+
+func liveCell(blockNumber uint64, txIndex uint, index uint, owner byte) *indexer.LiveCell {
+	return &indexer.LiveCell{
+		BlockNumber: blockNumber,
+		OutPoint:    &types.OutPoint{TxHash: types.BytesToHash([]byte{byte(blockNumber), byte(txIndex)}), Index: index},
+		Output: &types.CellOutput{
+			Capacity: 100 * 100000000,
+			Lock:     &types.Script{CodeHash: lockCode, HashType: types.HashTypeType, Args: []byte{owner}},
+		},
+		TxIndex: txIndex,
+	}
+}
+
+func TestGetCellsCapacities(t *testing.T) {
+	server := &indexerServer{tip: 16}
+	keys := make([]*indexer.SearchKey, 250)
+	for i := range keys {
+		keys[i] = searchKey(byte(i))
+		for j := 0; j < i%3; j++ {
+			server.cells = append(server.cells, liveCell(uint64(i), 0, uint(j), byte(i)))
+		}
+	}
+	client := testClient(t, server)
+	result, err := client.GetCellsCapacities(context.Background(), keys, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.requests != 3 {
+		t.Errorf("sent %d batches for %d search keys", server.requests, len(keys))
+	}
+	if len(result.Capacities) != len(keys) || result.Capacity != 249*100*100000000 || result.BlockNumber != 16 {
+		t.Fatalf("aggregated %d capacities of %d shannons at block %d", len(result.Capacities), result.Capacity, result.BlockNumber)
+	}
+	for i, capacity := range result.Capacities {
+		if capacity.Capacity != uint64(i%3)*100*100000000 {
+			t.Errorf("capacity of search key %d is %d", i, capacity.Capacity)
+		}
+	}
+
+	keys[180] = searchKey(0xff)
+	if _, err := client.GetCellsCapacities(context.Background(), keys, 2); err == nil || !strings.Contains(err.Error(), "search key 180") {
+		t.Errorf("rejected search key failed with %v", err)
+	}
+}
+
+func TestGetMergedCells(t *testing.T) {
+	server := &indexerServer{tip: 16, cells: []*indexer.LiveCell{
+		liveCell(1, 0, 0, 1),
+		liveCell(2, 0, 0, 2),
+		liveCell(3, 1, 0, 1),
+		liveCell(3, 1, 1, 2),
+		liveCell(5, 0, 0, 1),
+	}}
+	client := testClient(t, server)
+	// the empty args key finds every cell again, the stream returns each of them once.
+	keys := []*indexer.SearchKey{searchKey(1), searchKey(2), searchKey()}
+	for _, order := range []indexer.SearchOrder{indexer.SearchOrderAsc, indexer.SearchOrderDesc} {
+		stream, err := client.GetMergedCells(context.Background(), keys, order, 2, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []*indexer.LiveCell
+		for {
+			cell, err := stream.Next(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cell == nil {
+				break
+			}
+			got = append(got, cell)
+		}
+		if len(got) != len(server.cells) {
+			t.Fatalf("%s stream returned %d cells", order, len(got))
+		}
+		for i, cell := range got {
+			want := server.cells[i]
+			if order == indexer.SearchOrderDesc {
+				want = server.cells[len(server.cells)-1-i]
+			}
+			if indexer.CompareLiveCells(cell, want) != 0 {
+				t.Errorf("%s stream cell %d is at block %d index %d", order, i, cell.BlockNumber, cell.OutPoint.Index)
+			}
+		}
+		if stream.BlockNumber != 16 {
+			t.Errorf("%s stream block number is %d", order, stream.BlockNumber)
+		}
+	}
+
+	if _, err := client.GetMergedCells(context.Background(), []*indexer.SearchKey{searchKey(0xff)}, indexer.SearchOrderAsc, 2, 0); err == nil {
+		t.Error("merged the cells of a rejected search key")
+	}
+}
+
+func TestGetMergedCellsPageFailure(t *testing.T) {
+	server := &indexerServer{tip: 16, cells: []*indexer.LiveCell{
+		liveCell(1, 0, 0, 1),
+		liveCell(2, 0, 0, 1),
+		liveCell(3, 0, 0, 1),
+	}}
+	client := testClient(t, server)
+	stream, err := client.GetMergedCells(context.Background(), []*indexer.SearchKey{searchKey(1)}, indexer.SearchOrderAsc, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() *indexer.LiveCell {
+		cell, err := stream.Next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cell
+	}
+	if cell := next(); cell == nil || cell.BlockNumber != 1 {
+		t.Fatalf("first cell is %+v", cell)
+	}
+
+	// the second page fails, the cell before it stays in the stream for the next call.
+	server.mu.Lock()
+	server.failPages = true
+	server.mu.Unlock()
+	for i := 0; i < 2; i++ {
+		if _, err := stream.Next(context.Background()); err == nil {
+			t.Fatal("read the stream past a failed page")
+		}
+	}
+	server.mu.Lock()
+	server.failPages = false
+	server.mu.Unlock()
+	for _, want := range []uint64{2, 3} {
+		if cell := next(); cell == nil || cell.BlockNumber != want {
+			t.Fatalf("got cell %+v, want the cell of block %d", cell, want)
+		}
+	}
+	if cell := next(); cell != nil {
+		t.Errorf("stream continued with %+v", cell)
+	}
+
+	if _, err := client.GetMergedCells(context.Background(), []*indexer.SearchKey{searchKey(1)}, indexer.SearchOrderAsc, 0, 0); err == nil {
+		t.Error("merged cells with a zero page size")
+	}
+}
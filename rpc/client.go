@@ -127,6 +127,22 @@ type Client interface {
 	// GetTransactions returns the transactions collection by the lock or type script.
 	GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.Transactions, error)
 
+	// BatchGetCells returns the live cells collections of several search keys in a single batch call.
+	BatchGetCells(ctx context.Context, batch []indexer.BatchGetCellsItem) error
+
+	// BatchGetCellsCapacity returns the live cells capacities of several search keys in a single batch call.
+	BatchGetCellsCapacity(ctx context.Context, batch []indexer.BatchGetCellsCapacityItem) error
+
+	// BatchIndexer sends all requests collected by the indexer batch request in a single batch call.
+	BatchIndexer(ctx context.Context, batch *indexer.BatchRequest) error
+
+	// GetCellsCapacities returns the live cells capacity of every search key and their sum,
+	// querying the indexer in batches with at most concurrency batches in flight.
+	GetCellsCapacities(ctx context.Context, searchKeys []*indexer.SearchKey, concurrency int) (*AggregatedCapacity, error)
+
+	// GetMergedCells returns a stream of the live cells of all search keys merged in the given order.
+	GetMergedCells(ctx context.Context, searchKeys []*indexer.SearchKey, order indexer.SearchOrder, pageSize uint64, concurrency int) (*CellStream, error)
+
 	// Close close client
 	Close()
 }
@@ -296,3 +312,15 @@ func (cli *client) GetCells(ctx context.Context, searchKey *indexer.SearchKey, o
 func (cli *client) GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.Transactions, error) {
 	return cli.indexer.GetTransactions(ctx, searchKey, order, limit, afterCursor)
 }
+
+func (cli *client) BatchGetCells(ctx context.Context, batch []indexer.BatchGetCellsItem) error {
+	return cli.indexer.BatchGetCells(ctx, batch)
+}
+
+func (cli *client) BatchGetCellsCapacity(ctx context.Context, batch []indexer.BatchGetCellsCapacityItem) error {
+	return cli.indexer.BatchGetCellsCapacity(ctx, batch)
+}
+
+func (cli *client) BatchIndexer(ctx context.Context, batch *indexer.BatchRequest) error {
+	return cli.indexer.BatchCall(ctx, batch)
+}
@@ -0,0 +1,204 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+)
+
+type SnapshotPolicy int
+
+const (
+	// SnapshotPolicyFail returns ErrSnapshotChanged as soon as the indexer tip moves.
+	SnapshotPolicyFail SnapshotPolicy = iota
+	// SnapshotPolicyRestart records a new tip and restarts the whole scan when the indexer tip moves.
+	SnapshotPolicyRestart
+)
+
+// DefaultMaxRestarts is the number of restarts allowed by SnapshotPolicyRestart before giving up.
+const DefaultMaxRestarts = 3
+
+var ErrSnapshotChanged = errors.New("indexer snapshot changed")
+
+// SnapshotChangedError reports that the indexer tip moved away from the tip recorded by a snapshot.
+// It matches ErrSnapshotChanged with errors.Is.
+type SnapshotChangedError struct {
+	Start   *indexer.TipHeader
+	Current *indexer.TipHeader
+	// Reorg is true when the recorded tip block is no longer on the main chain.
+	Reorg bool
+}
+
+func (e *SnapshotChangedError) Error() string {
+	if e.Reorg {
+		return fmt.Sprintf("indexer snapshot changed: block %d %s was rolled back, tip is block %d %s",
+			e.Start.BlockNumber, e.Start.BlockHash.String(), e.Current.BlockNumber, e.Current.BlockHash.String())
+	}
+	return fmt.Sprintf("indexer snapshot changed: tip moved from block %d %s to block %d %s",
+		e.Start.BlockNumber, e.Start.BlockHash.String(), e.Current.BlockNumber, e.Current.BlockHash.String())
+}
+
+func (e *SnapshotChangedError) Is(target error) bool {
+	return target == ErrSnapshotChanged
+}
+
+// Snapshot pages the indexer against the tip recorded at its start,
+// so that results collected across several calls describe the same chain state.
+type Snapshot struct {
+	client      Client
+	Policy      SnapshotPolicy
+	MaxRestarts int
+	tip         *indexer.TipHeader
+}
+
+func NewSnapshot(ctx context.Context, client Client, policy SnapshotPolicy) (*Snapshot, error) {
+	s := &Snapshot{
+		client:      client,
+		Policy:      policy,
+		MaxRestarts: DefaultMaxRestarts,
+	}
+	err := s.Reset(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Tip returns the indexer tip recorded by the snapshot.
+func (s *Snapshot) Tip() *indexer.TipHeader {
+	return s.tip
+}
+
+// Reset records the current indexer tip as the snapshot tip.
+func (s *Snapshot) Reset(ctx context.Context) error {
+	tip, err := s.client.GetTip(ctx)
+	if err != nil {
+		return err
+	}
+	s.tip = tip
+	return nil
+}
+
+// GetCells returns a page of live cells, or a *SnapshotChangedError when the indexer tip
+// is not the snapshot tip anymore.
+func (s *Snapshot) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	batch := indexer.NewBatchRequest()
+	item := batch.AddGetCells(searchKey, order, limit, afterCursor)
+	tip := batch.AddGetTip()
+	err := s.call(ctx, batch, tip)
+	if err != nil {
+		return nil, err
+	}
+	if item.Error != nil {
+		return nil, item.Error
+	}
+	return item.Result, nil
+}
+
+// GetTransactions returns a page of transactions, or a *SnapshotChangedError when the indexer tip
+// is not the snapshot tip anymore.
+func (s *Snapshot) GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.Transactions, error) {
+	batch := indexer.NewBatchRequest()
+	item := batch.AddGetTransactions(searchKey, order, limit, afterCursor)
+	tip := batch.AddGetTip()
+	err := s.call(ctx, batch, tip)
+	if err != nil {
+		return nil, err
+	}
+	if item.Error != nil {
+		return nil, item.Error
+	}
+	return item.Result, nil
+}
+
+// GetCellsCapacity returns the live cells capacity, or a *SnapshotChangedError when the capacity
+// was not computed at the snapshot tip.
+func (s *Snapshot) GetCellsCapacity(ctx context.Context, searchKey *indexer.SearchKey) (*indexer.Capacity, error) {
+	capacity, err := s.client.GetCellsCapacity(ctx, searchKey)
+	if err != nil {
+		return nil, err
+	}
+	err = s.check(ctx, &indexer.TipHeader{
+		BlockHash:   capacity.BlockHash,
+		BlockNumber: capacity.BlockNumber,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return capacity, nil
+}
+
+// GetAllCells pages through all live cells of the search key. When the indexer tip moves it
+// returns the *SnapshotChangedError, or restarts from a new tip under SnapshotPolicyRestart.
+func (s *Snapshot) GetAllCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, pageSize uint64) ([]*indexer.LiveCell, error) {
+	if pageSize == 0 {
+		return nil, errors.New("page size is zero")
+	}
+	restarts := 0
+	for {
+		cells, err := s.getAllCells(ctx, searchKey, order, pageSize)
+		if err == nil {
+			return cells, nil
+		}
+		if s.Policy != SnapshotPolicyRestart || !errors.Is(err, ErrSnapshotChanged) || restarts >= s.MaxRestarts {
+			return nil, err
+		}
+		restarts++
+		err = s.Reset(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *Snapshot) getAllCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, pageSize uint64) ([]*indexer.LiveCell, error) {
+	var result []*indexer.LiveCell
+	cursor := ""
+	for {
+		cells, err := s.GetCells(ctx, searchKey, order, pageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, cells.Objects...)
+		if uint64(len(cells.Objects)) < pageSize {
+			return result, nil
+		}
+		cursor = cells.LastCursor
+	}
+}
+
+func (s *Snapshot) call(ctx context.Context, batch *indexer.BatchRequest, tip *indexer.BatchGetTipItem) error {
+	err := s.client.BatchIndexer(ctx, batch)
+	if err != nil {
+		return err
+	}
+	if tip.Error != nil {
+		return tip.Error
+	}
+	return s.check(ctx, tip.Result)
+}
+
+func (s *Snapshot) check(ctx context.Context, current *indexer.TipHeader) error {
+	if current.BlockHash == s.tip.BlockHash {
+		return nil
+	}
+	reorg, err := s.rolledBack(ctx)
+	if err != nil {
+		return err
+	}
+	return &SnapshotChangedError{
+		Start:   s.tip,
+		Current: current,
+		Reorg:   reorg,
+	}
+}
+
+func (s *Snapshot) rolledBack(ctx context.Context) (bool, error) {
+	hash, err := s.client.GetBlockHash(ctx, s.tip.BlockNumber)
+	if err != nil {
+		return false, err
+	}
+	return hash == nil || *hash != s.tip.BlockHash, nil
+}
@@ -0,0 +1,132 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+)
+
+// snapshotServer returns an indexer of three cells of owner 1 at tip 16, whose onGetCells calls move after the given
+// number of get_cells requests.
+func snapshotServer(requests int, move func(s *indexerServer)) *indexerServer {
+	count := 0
+	return &indexerServer{
+		tip: 16,
+		cells: []*indexer.LiveCell{
+			liveCell(1, 0, 0, 1),
+			liveCell(3, 1, 0, 1),
+			liveCell(5, 0, 0, 1),
+		},
+		onGetCells: func(s *indexerServer) {
+			count++
+			if count == requests {
+				move(s)
+			}
+		},
+	}
+}
+
+func advance(s *indexerServer) {
+	s.tip++
+}
+
+func reorganize(s *indexerServer) {
+	s.fork = true
+}
+
+func TestSnapshotGetAllCells(t *testing.T) {
+	server := snapshotServer(0, nil)
+	snapshot, err := NewSnapshot(context.Background(), testClient(t, server), SnapshotPolicyFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells, err := snapshot.GetAllCells(context.Background(), searchKey(1), indexer.SearchOrderAsc, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cells) != 3 || snapshot.Tip().BlockNumber != 16 {
+		t.Errorf("got %d cells at tip %d", len(cells), snapshot.Tip().BlockNumber)
+	}
+	// every page is a single request together with the tip.
+	if server.requests != 3 {
+		t.Errorf("paged in %d requests", server.requests)
+	}
+	if _, err := snapshot.GetAllCells(context.Background(), searchKey(1), indexer.SearchOrderAsc, 0); err == nil {
+		t.Error("paged with a zero page size")
+	}
+}
+
+func TestSnapshotChanged(t *testing.T) {
+	tests := []struct {
+		name  string
+		move  func(s *indexerServer)
+		reorg bool
+	}{
+		{"tip moved", advance, false},
+		{"tip rolled back", reorganize, true},
+	}
+	for _, tt := range tests {
+		server := snapshotServer(2, tt.move)
+		snapshot, err := NewSnapshot(context.Background(), testClient(t, server), SnapshotPolicyFail)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = snapshot.GetAllCells(context.Background(), searchKey(1), indexer.SearchOrderAsc, 2)
+		var changed *SnapshotChangedError
+		if !errors.Is(err, ErrSnapshotChanged) || !errors.As(err, &changed) {
+			t.Fatalf("%s: paging failed with %v", tt.name, err)
+		}
+		if changed.Reorg != tt.reorg || changed.Start.BlockNumber != 16 {
+			t.Errorf("%s: %v", tt.name, changed)
+		}
+	}
+}
+
+func TestSnapshotRestart(t *testing.T) {
+	server := snapshotServer(2, advance)
+	snapshot, err := NewSnapshot(context.Background(), testClient(t, server), SnapshotPolicyRestart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells, err := snapshot.GetAllCells(context.Background(), searchKey(1), indexer.SearchOrderAsc, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cells) != 3 || snapshot.Tip().BlockNumber != 17 {
+		t.Errorf("got %d cells at tip %d after the restart", len(cells), snapshot.Tip().BlockNumber)
+	}
+
+	server = snapshotServer(0, nil)
+	server.onGetCells = advance
+	snapshot, err = NewSnapshot(context.Background(), testClient(t, server), SnapshotPolicyRestart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.MaxRestarts = 2
+	if _, err := snapshot.GetAllCells(context.Background(), searchKey(1), indexer.SearchOrderAsc, 2); !errors.Is(err, ErrSnapshotChanged) {
+		t.Errorf("paging a moving tip failed with %v", err)
+	}
+}
+
+func TestSnapshotGetCellsCapacity(t *testing.T) {
+	server := snapshotServer(0, nil)
+	client := testClient(t, server)
+	snapshot, err := NewSnapshot(context.Background(), client, SnapshotPolicyFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	capacity, err := snapshot.GetCellsCapacity(context.Background(), searchKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capacity.Capacity != 300*100000000 {
+		t.Errorf("capacity is %d", capacity.Capacity)
+	}
+
+	advance(server)
+	if _, err := snapshot.GetCellsCapacity(context.Background(), searchKey(1)); !errors.Is(err, ErrSnapshotChanged) {
+		t.Errorf("capacity at another tip failed with %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package signer
+
+import (
+	"math/big"
+
+	ethsecp256k1 "github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	ckbtransaction "github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+// SignatureLength is the length of a recoverable secp256k1 signature.
+const SignatureLength = 65
+
+// Secp256k1Signer signs secp256k1-blake160-sighash-all script groups with the private keys whose
+// blake160 public key hash is the lock args.
+type Secp256k1Signer struct {
+	keys map[string]*secp256k1.Secp256k1Key
+}
+
+func NewSecp256k1Signer(keys ...*secp256k1.Secp256k1Key) (*Secp256k1Signer, error) {
+	s := &Secp256k1Signer{
+		keys: make(map[string]*secp256k1.Secp256k1Key, len(keys)),
+	}
+	for _, key := range keys {
+		err := s.AddKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Secp256k1Signer) AddKey(key *secp256k1.Secp256k1Key) error {
+	args, err := blake2b.Blake160(key.PubKey())
+	if err != nil {
+		return err
+	}
+	s.keys[string(args)] = key
+	return nil
+}
+
+func (s *Secp256k1Signer) SignGroup(tx *types.Transaction, group *transaction.ScriptGroup) (bool, error) {
+	if group.Script.CodeHash != types.HexToHash(ckbtransaction.SECP256K1_BLAKE160_SIGHASH_ALL_TYPE_HASH) || group.Script.HashType != types.HashTypeType {
+		return false, nil
+	}
+	key, ok := s.keys[string(group.Script.Args)]
+	if !ok {
+		return false, nil
+	}
+
+	message, witnessArgs, err := SighashAllMessage(tx, group, make([]byte, SignatureLength))
+	if err != nil {
+		return false, err
+	}
+	signature, err := key.Sign(message)
+	if err != nil {
+		return false, err
+	}
+	witnessArgs.Lock = signature
+	tx.Witnesses[group.InputIndices[0]] = molecule.SerializeWitnessArgs(witnessArgs)
+	return true, nil
+}
+
+// RecoverPubKeyHash returns the blake160 hash of the compressed public key which produced the signature of message.
+func RecoverPubKeyHash(message []byte, signature []byte) ([]byte, error) {
+	pub, err := ethsecp256k1.RecoverPubkey(message, signature)
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Int).SetBytes(pub[1:33])
+	y := new(big.Int).SetBytes(pub[33:65])
+	return blake2b.Blake160(ethsecp256k1.CompressPubkey(x, y))
+}
@@ -0,0 +1,82 @@
+package signer
+
+import (
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+// Signer unlocks the script groups of a transaction it holds the keys of.
+type Signer interface {
+	// SignGroup fills the witness of the script group and reports whether the signer could sign it.
+	SignGroup(tx *types.Transaction, group *transaction.ScriptGroup) (bool, error)
+}
+
+// SignTransaction signs every script group with the first signer able to, and fails when a group is left unsigned.
+func SignTransaction(tx *types.Transaction, groups []*transaction.ScriptGroup, signers ...Signer) error {
+	for _, group := range groups {
+		signed := false
+		for _, signer := range signers {
+			ok, err := signer.SignGroup(tx, group)
+			if err != nil {
+				return err
+			}
+			if ok {
+				signed = true
+				break
+			}
+		}
+		if !signed {
+			hash, err := group.Script.Hash()
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("no signer for script group %s", hash.String())
+		}
+	}
+	return nil
+}
+
+// SighashAllMessage returns the message signed by the sighash-all locks for the group:
+// the transaction hash, the first group witness with its lock replaced by lockPlaceholder, the other
+// group witnesses, and the witnesses without an input, each witness prefixed with its length.
+func SighashAllMessage(tx *types.Transaction, group *transaction.ScriptGroup, lockPlaceholder []byte) ([]byte, *types.WitnessArgs, error) {
+	if len(group.InputIndices) == 0 {
+		return nil, nil, fmt.Errorf("empty script group")
+	}
+	if len(tx.Witnesses) < len(tx.Inputs) {
+		return nil, nil, fmt.Errorf("transaction has %d inputs but %d witnesses", len(tx.Inputs), len(tx.Witnesses))
+	}
+
+	witnessArgs, err := molecule.DeserializeWitnessArgs(tx.Witnesses[group.InputIndices[0]])
+	if err != nil {
+		return nil, nil, err
+	}
+	witnessArgs.Lock = lockPlaceholder
+
+	hash, err := molecule.TransactionHash(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	message := hash.Bytes()
+	message = appendWitness(message, molecule.SerializeWitnessArgs(witnessArgs))
+	for _, index := range group.InputIndices[1:] {
+		message = appendWitness(message, tx.Witnesses[index])
+	}
+	for _, witness := range tx.Witnesses[len(tx.Inputs):] {
+		message = appendWitness(message, witness)
+	}
+
+	digest, err := molecule.Hash(message)
+	if err != nil {
+		return nil, nil, err
+	}
+	return digest.Bytes(), witnessArgs, nil
+}
+
+func appendWitness(message []byte, witness []byte) []byte {
+	message = append(message, molecule.Uint64(uint64(len(witness)))...)
+	return append(message, witness...)
+}
@@ -0,0 +1,147 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	ckbtransaction "github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+const (
+	// testKey is the private key of the dev chain genesis issued cells, whose lock args are testArgs.
+	testKey  = "d00c06bfd800d27397002dca6fb0993d5ba6399b4238b2f29ee9deb97593d2bc"
+	testArgs = "c8328aabcd9b9e8e64fbc566c4385c3bdeb219d7"
+)
+
+func testTransaction(locks ...*types.Script) *types.Transaction {
+	tx := &types.Transaction{
+		CellDeps: []*types.CellDep{{
+			OutPoint: &types.OutPoint{TxHash: types.HexToHash("0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c")},
+			DepType:  types.DepTypeDepGroup,
+		}},
+		HeaderDeps: []types.Hash{},
+		Outputs: []*types.CellOutput{{
+			Capacity: 6100000000,
+			Lock:     locks[0],
+		}},
+		OutputsData: [][]byte{{}},
+	}
+	for i := range locks {
+		tx.Inputs = append(tx.Inputs, &types.CellInput{
+			PreviousOutput: &types.OutPoint{
+				TxHash: types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541"),
+				Index:  uint(i),
+			},
+		})
+		tx.Witnesses = append(tx.Witnesses, []byte{})
+	}
+	return tx
+}
+
+func testLock(t *testing.T, args string) *types.Script {
+	data, err := hex.DecodeString(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.Script{
+		CodeHash: types.HexToHash(ckbtransaction.SECP256K1_BLAKE160_SIGHASH_ALL_TYPE_HASH),
+		HashType: types.HashTypeType,
+		Args:     data,
+	}
+}
+
+func TestSighashAllMessage(t *testing.T) {
+	lock := testLock(t, testArgs)
+	tx := testTransaction(lock, lock)
+	group := &transaction.ScriptGroup{Script: lock, InputIndices: []int{0, 1}}
+
+	message, witnessArgs, err := SighashAllMessage(tx, group, make([]byte, SignatureLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(witnessArgs.Lock) != SignatureLength {
+		t.Errorf("placeholder lock is %d bytes", len(witnessArgs.Lock))
+	}
+	// the upstream SDK computes the message of a group of consecutive inputs independently.
+	want, err := ckbtransaction.SingleSegmentSignMessage(tx, 0, 2, &types.WitnessArgs{Lock: make([]byte, SignatureLength)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(message, want) {
+		t.Errorf("message is %x, upstream computes %x", message, want)
+	}
+
+	// the witnesses of the other group inputs and those without an input are signed too.
+	tx.Witnesses[1] = []byte{1}
+	other, _, err := SighashAllMessage(tx, group, make([]byte, SignatureLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Witnesses = append(tx.Witnesses, []byte{2})
+	extra, _, err := SighashAllMessage(tx, group, make([]byte, SignatureLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(other, message) || bytes.Equal(extra, other) {
+		t.Error("message does not cover the group and extra witnesses")
+	}
+
+	tx.Witnesses = tx.Witnesses[:1]
+	if _, _, err := SighashAllMessage(tx, group, make([]byte, SignatureLength)); err == nil {
+		t.Error("message of a transaction missing witnesses")
+	}
+}
+
+func TestSecp256k1Signer(t *testing.T) {
+	key, err := secp256k1.HexToKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSecp256k1Signer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := testLock(t, testArgs)
+	other := testLock(t, "0000000000000000000000000000000000000000")
+	tx := testTransaction(lock, other, lock)
+	groups, err := transaction.GroupInputs([]*types.Script{lock, other, lock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, _, err := SighashAllMessage(tx, groups[0], make([]byte, SignatureLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := s.SignGroup(tx, groups[0])
+	if err != nil || !ok {
+		t.Fatalf("SignGroup returned %v, %v", ok, err)
+	}
+	witnessArgs, err := molecule.DeserializeWitnessArgs(tx.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := RecoverPubKeyHash(message, witnessArgs.Lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(hash) != testArgs {
+		t.Errorf("signature recovers public key hash %x, want %s", hash, testArgs)
+	}
+	if len(tx.Witnesses[1]) != 0 || len(tx.Witnesses[2]) != 0 {
+		t.Error("signing the group changed the witnesses of other inputs")
+	}
+
+	ok, err = s.SignGroup(tx, groups[1])
+	if err != nil || ok {
+		t.Errorf("SignGroup of a lock without key returned %v, %v", ok, err)
+	}
+	if err := SignTransaction(tx, groups, s); err == nil {
+		t.Error("SignTransaction left a group unsigned without error")
+	}
+}
@@ -0,0 +1,125 @@
+// Package since encodes and decodes the since of transaction inputs, and checks whether a since is reached.
+package since
+
+import (
+	"fmt"
+
+	"github.com/shaojunda/ckb-rich-sdk-go/epoch"
+)
+
+const (
+	// FlagRelative marks a since measured from the block of the input cell instead of the genesis block.
+	FlagRelative = 1 << 63
+	// FlagEpoch selects the epoch with fraction metric.
+	FlagEpoch = 1 << 61
+	// FlagTimestamp selects the median timestamp metric, in seconds. The block number metric has no flag.
+	FlagTimestamp = 1 << 62
+
+	metricMask   = 3 << 61
+	reservedMask = 0x1f << 56
+	valueMask    = 1<<56 - 1
+)
+
+// Metric is what a since counts.
+type Metric int
+
+const (
+	MetricBlockNumber Metric = iota
+	MetricEpoch
+	MetricTimestamp
+)
+
+func (m Metric) String() string {
+	switch m {
+	case MetricBlockNumber:
+		return "block number"
+	case MetricEpoch:
+		return "epoch"
+	case MetricTimestamp:
+		return "timestamp"
+	}
+	return fmt.Sprintf("Metric(%d)", int(m))
+}
+
+// Since is a decoded input since.
+type Since struct {
+	Relative bool
+	Metric   Metric
+	// Value is the block number, the epoch with fraction as a header encodes it, or the median timestamp in seconds.
+	Value uint64
+}
+
+// BlockNumber returns the since of a block number, or of a number of blocks after the input cell when relative.
+func BlockNumber(relative bool, number uint64) *Since {
+	return &Since{
+		Relative: relative,
+		Metric:   MetricBlockNumber,
+		Value:    number,
+	}
+}
+
+// Epoch returns the since of an epoch with fraction, or of a number of epochs after the input cell when relative.
+func Epoch(relative bool, e *epoch.Epoch) *Since {
+	return &Since{
+		Relative: relative,
+		Metric:   MetricEpoch,
+		Value:    e.Uint64(),
+	}
+}
+
+// Timestamp returns the since of a median timestamp in seconds, or of a number of seconds after the input cell when relative.
+func Timestamp(relative bool, seconds uint64) *Since {
+	return &Since{
+		Relative: relative,
+		Metric:   MetricTimestamp,
+		Value:    seconds,
+	}
+}
+
+// Parse decodes an input since, zero is a since without constraint.
+func Parse(since uint64) (*Since, error) {
+	if since&reservedMask != 0 {
+		return nil, fmt.Errorf("since %#x has reserved bits set", since)
+	}
+	s := &Since{
+		Relative: since&FlagRelative != 0,
+		Value:    since & valueMask,
+	}
+	switch since & metricMask {
+	case 0:
+		s.Metric = MetricBlockNumber
+	case FlagEpoch:
+		s.Metric = MetricEpoch
+	case FlagTimestamp:
+		s.Metric = MetricTimestamp
+	default:
+		return nil, fmt.Errorf("since %#x has an invalid metric", since)
+	}
+	return s, nil
+}
+
+// Uint64 encodes the since.
+func (s *Since) Uint64() (uint64, error) {
+	if s.Value&^valueMask != 0 {
+		return 0, fmt.Errorf("since %s %d does not fit in 56 bits", s.Metric, s.Value)
+	}
+	since := s.Value
+	if s.Relative {
+		since |= FlagRelative
+	}
+	switch s.Metric {
+	case MetricBlockNumber:
+	case MetricEpoch:
+		since |= FlagEpoch
+	case MetricTimestamp:
+		since |= FlagTimestamp
+	default:
+		return 0, fmt.Errorf("invalid since metric %s", s.Metric)
+	}
+	return since, nil
+}
+
+// Epoch returns the epoch with fraction of an epoch since.
+func (s *Since) Epoch() *epoch.Epoch {
+	return epoch.Parse(s.Value)
+}
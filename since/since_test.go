@@ -0,0 +1,146 @@
+package since
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/epoch"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		since uint64
+		want  Since
+	}{
+		{0, Since{Metric: MetricBlockNumber}},
+		{0x0000000000000064, Since{Metric: MetricBlockNumber, Value: 100}},
+		{0x8000000000000064, Since{Relative: true, Metric: MetricBlockNumber, Value: 100}},
+		// the unlock since of a DAO withdraw: epoch 180 and 7/10.
+		{0x20000a00070000b4, Since{Metric: MetricEpoch, Value: 0x00000a00070000b4}},
+		// the cheque withdraw since: 6 epochs after the cheque cell.
+		{0xa000000000000006, Since{Relative: true, Metric: MetricEpoch, Value: 6}},
+		{0x400000005f5e1000, Since{Metric: MetricTimestamp, Value: 0x5f5e1000}},
+		{0xc000000000015180, Since{Relative: true, Metric: MetricTimestamp, Value: 86400}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.since)
+		if err != nil {
+			t.Fatalf("%#x: %v", tt.since, err)
+		}
+		if *s != tt.want {
+			t.Errorf("since %#x is %+v, want %+v", tt.since, *s, tt.want)
+		}
+		encoded, err := s.Uint64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoded != tt.since {
+			t.Errorf("since %+v encodes to %#x, want %#x", *s, encoded, tt.since)
+		}
+	}
+
+	for _, invalid := range []uint64{0x0100000000000000, 0x1f00000000000000, 0x6000000000000000} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("parsed since %#x", invalid)
+		}
+	}
+	if _, err := BlockNumber(false, 1<<56).Uint64(); err == nil {
+		t.Error("encoded a block number above 56 bits")
+	}
+}
+
+func TestEpoch(t *testing.T) {
+	s := Epoch(false, epoch.New(180, 7, 10))
+	value, err := s.Uint64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 0x20000a00070000b4 {
+		t.Errorf("epoch since is %#x, want 0x20000a00070000b4", value)
+	}
+	if e := s.Epoch(); *e != *epoch.New(180, 7, 10) {
+		t.Errorf("since epoch is %s, want 180(7/10)", e)
+	}
+}
+
+func TestReached(t *testing.T) {
+	cell := &Block{Number: 1000, Epoch: epoch.New(10, 500, 1000).Uint64(), MedianTime: 1600000000000}
+	tests := []struct {
+		name  string
+		since *Since
+		at    *Block
+		want  bool
+	}{
+		{"absolute block", BlockNumber(false, 2000), &Block{Number: 2000}, true},
+		{"absolute block early", BlockNumber(false, 2000), &Block{Number: 1999}, false},
+		{"relative block", BlockNumber(true, 100), &Block{Number: 1100}, true},
+		{"relative block early", BlockNumber(true, 100), &Block{Number: 1099}, false},
+		{"absolute epoch", Epoch(false, epoch.New(11, 1, 2)), &Block{Epoch: epoch.New(11, 900, 1800).Uint64()}, true},
+		{"absolute epoch early", Epoch(false, epoch.New(11, 1, 2)), &Block{Epoch: epoch.New(11, 899, 1800).Uint64()}, false},
+		{"relative epochs", Epoch(true, epoch.New(6, 0, 0)), &Block{Epoch: epoch.New(16, 500, 1000).Uint64()}, true},
+		{"relative epochs early", Epoch(true, epoch.New(6, 0, 0)), &Block{Epoch: epoch.New(16, 499, 1000).Uint64()}, false},
+		{"absolute timestamp", Timestamp(false, 1600000000), &Block{MedianTime: 1600000000000}, true},
+		{"absolute timestamp early", Timestamp(false, 1600000000), &Block{MedianTime: 1599999999999}, false},
+		{"relative timestamp", Timestamp(true, 60), &Block{MedianTime: 1600000060000}, true},
+		{"relative timestamp early", Timestamp(true, 60), &Block{MedianTime: 1600000059999}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.since.Reached(tt.at, cell); got != tt.want {
+			t.Errorf("%s: reached is %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// headersClient serves the headers of a chain of blocks one second apart, leaving the other methods unimplemented.
+type headersClient struct {
+	rpc.Client
+	tip  uint64
+	cell *types.Header
+}
+
+func (c *headersClient) GetTipHeader(ctx context.Context) (*types.Header, error) {
+	return c.GetHeaderByNumber(ctx, c.tip)
+}
+
+func (c *headersClient) GetHeader(ctx context.Context, hash types.Hash) (*types.Header, error) {
+	return c.cell, nil
+}
+
+func (c *headersClient) GetHeaderByNumber(ctx context.Context, number uint64) (*types.Header, error) {
+	return &types.Header{
+		Number:    number,
+		Epoch:     epoch.New(number/1000, number%1000, 1000).Uint64(),
+		Timestamp: number * 1000,
+	}, nil
+}
+
+func TestSpendable(t *testing.T) {
+	client := &headersClient{tip: 2099}
+	client.cell, _ = client.GetHeaderByNumber(context.Background(), 1100)
+
+	tests := []struct {
+		name  string
+		since uint64
+		want  bool
+	}{
+		{"no since", 0, true},
+		{"next block", 2100, true},
+		{"after next block", 2101, false},
+		{"relative blocks", FlagRelative | 1000, true},
+		{"relative epoch", FlagRelative | FlagEpoch | epoch.New(1, 1, 1000).Uint64(), false},
+		// the median time of the tip is the timestamp of block 2081, the cell is measured from block 1081.
+		{"relative seconds", FlagRelative | FlagTimestamp | 1000, true},
+		{"relative seconds early", FlagRelative | FlagTimestamp | 1001, false},
+	}
+	for _, tt := range tests {
+		got, err := Spendable(context.Background(), client, tt.since, types.Hash{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: spendable is %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package since
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/epoch"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+// medianTimeBlocks is the number of blocks whose median timestamp a timestamp since is measured by.
+const medianTimeBlocks = 37
+
+// Block is a block a since is measured at.
+type Block struct {
+	Number uint64
+	// Epoch is the epoch with fraction as a header encodes it.
+	Epoch uint64
+	// MedianTime is the median timestamp in milliseconds of the blocks before the block.
+	MedianTime uint64
+}
+
+// Reached reports whether an input with the since can be committed in the block at. The block of the input cell is
+// only read by relative since.
+func (s *Since) Reached(at *Block, cell *Block) bool {
+	if s.Relative {
+		switch s.Metric {
+		case MetricBlockNumber:
+			return at.Number >= cell.Number && at.Number-cell.Number >= s.Value
+		case MetricEpoch:
+			return epoch.Parse(at.Epoch).Cmp(epoch.Parse(cell.Epoch).Add(s.Epoch())) >= 0
+		case MetricTimestamp:
+			return at.MedianTime >= cell.MedianTime && at.MedianTime-cell.MedianTime >= s.Value*1000
+		}
+		return false
+	}
+	switch s.Metric {
+	case MetricBlockNumber:
+		return at.Number >= s.Value
+	case MetricEpoch:
+		return epoch.Parse(at.Epoch).Cmp(s.Epoch()) >= 0
+	case MetricTimestamp:
+		return at.MedianTime >= s.Value*1000
+	}
+	return false
+}
+
+// Spendable reports whether an input with the since can be committed in the block after the tip, whose epoch is
+// taken as the tip epoch. cellBlockHash is the block of the input cell, only read by relative since.
+func Spendable(ctx context.Context, client rpc.Client, since uint64, cellBlockHash types.Hash) (bool, error) {
+	s, err := Parse(since)
+	if err != nil {
+		return false, err
+	}
+	if since == 0 {
+		return true, nil
+	}
+	tip, err := client.GetTipHeader(ctx)
+	if err != nil {
+		return false, err
+	}
+	at := &Block{
+		Number: tip.Number + 1,
+		Epoch:  tip.Epoch,
+	}
+	if s.Metric == MetricTimestamp {
+		at.MedianTime, err = MedianTime(ctx, client, tip.Number)
+		if err != nil {
+			return false, err
+		}
+	}
+	if !s.Relative {
+		return s.Reached(at, nil), nil
+	}
+
+	header, err := client.GetHeader(ctx, cellBlockHash)
+	if err != nil {
+		return false, err
+	}
+	cell := &Block{
+		Number: header.Number,
+		Epoch:  header.Epoch,
+	}
+	if s.Metric == MetricTimestamp {
+		parent := header.Number
+		if parent > 0 {
+			parent--
+		}
+		cell.MedianTime, err = MedianTime(ctx, client, parent)
+		if err != nil {
+			return false, err
+		}
+	}
+	return s.Reached(at, cell), nil
+}
+
+// MedianTime returns the median timestamp in milliseconds of the 37 blocks ending at the block number, the median
+// time of the block after it.
+func MedianTime(ctx context.Context, client rpc.Client, number uint64) (uint64, error) {
+	var timestamps []uint64
+	for i := uint64(0); i < medianTimeBlocks && i <= number; i++ {
+		header, err := client.GetHeaderByNumber(ctx, number-i)
+		if err != nil {
+			return 0, err
+		}
+		timestamps = append(timestamps, header.Timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+	return timestamps[len(timestamps)/2], nil
+}
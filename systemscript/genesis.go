@@ -0,0 +1,59 @@
+package systemscript
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+// FromGenesis discovers the scripts of the genesis block of the chain the client connects to, such as a devnet: the
+// secp256k1-blake160 sighash and multisig locks with their dep groups, and the DAO. The other scripts are deployed
+// after the genesis block, Register them when the chain has them.
+func FromGenesis(ctx context.Context, client rpc.Client) (*Registry, error) {
+	genesis, err := client.GetBlockByNumber(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(genesis.Transactions) < 2 || len(genesis.Transactions[0].Outputs) < 5 || len(genesis.Transactions[1].Outputs) < 2 {
+		return nil, errors.New("genesis block does not hold the system scripts")
+	}
+	cells := genesis.Transactions[0]
+	groups := genesis.Transactions[1]
+
+	r := NewRegistry()
+	for _, s := range []struct {
+		name    Name
+		output  uint
+		txHash  types.Hash
+		index   uint
+		depType types.DepType
+	}{
+		{Secp256k1Blake160, 1, groups.Hash, 0, types.DepTypeDepGroup},
+		{DAO, 2, cells.Hash, 2, types.DepTypeCode},
+		{Multisig, 4, groups.Hash, 1, types.DepTypeDepGroup},
+	} {
+		typeScript := cells.Outputs[s.output].Type
+		if typeScript == nil {
+			return nil, errors.New("genesis system cell has no type id")
+		}
+		codeHash, err := molecule.ScriptHash(typeScript)
+		if err != nil {
+			return nil, err
+		}
+		r.Register(s.name, &Script{
+			CodeHash: codeHash,
+			HashType: types.HashTypeType,
+			CellDep: &types.CellDep{
+				OutPoint: &types.OutPoint{
+					TxHash: s.txHash,
+					Index:  s.index,
+				},
+				DepType: s.depType,
+			},
+		})
+	}
+	return r, nil
+}
@@ -0,0 +1,49 @@
+package systemscript
+
+import (
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+)
+
+var (
+	// Mainnet is the registry of the mainnet deployments.
+	Mainnet = &Registry{
+		scripts: map[Name]*Script{
+			Secp256k1Blake160: deployment("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8", types.HashTypeType, "0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c", 0, types.DepTypeDepGroup),
+			Multisig:          deployment("0x5c5069eb0857efc65e1bca0c07df34c31663b3622fd3876c876320fc9634e2a8", types.HashTypeType, "0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c", 1, types.DepTypeDepGroup),
+			DAO:               deployment("0x82d76d1b75fe2fd9a27dfbaa65a039221a380d76c926f378d3f81cf3e7e13f2e", types.HashTypeType, "0xe2fb199810d49a4d8beec56718ba2593b665db9d52299a0f9e6e75416d73ff5c", 2, types.DepTypeCode),
+			SUDT:              deployment("0x5e7a36a77e68eecc013dfa2fe6a23f3b6c344b04005808694ae6dd45eea4cfd5", types.HashTypeType, "0xc7813f6a415144643970c2e88e0bb6ca6a8edc5dd7c1022746f628284a9936d5", 0, types.DepTypeCode),
+			XUDT:              deployment("0x50bd8d6680b8b9cf98b73f3c08faf8b2a21914311954118ad6609be6e78a1b95", molecule.HashTypeData1, "0xc07844ce21b38e4b071dd0e1ee3b0e27afd8d7532491327f39b786343f558ab7", 0, types.DepTypeCode),
+			ACP:               deployment("0xd369597ff47f29fbc0d47d2e3775370d1250b85140c670e4718af712983a2354", types.HashTypeType, "0x4153a2014952d7cac45f285ce9a7c5c0c0e1b21f2d378b82ac1433cb11c25c4d", 0, types.DepTypeDepGroup),
+			Cheque:            deployment("0xe4d4ecc6e5f9a059bf2f7a82cca292083aebc0c421566a52484fe2ec51a9fb0c", types.HashTypeType, "0x04632cc459459cf5c9d384b43dee3e36f542a464bdd4127be7d6618ac6f8d268", 0, types.DepTypeDepGroup),
+			Omnilock:          deployment("0x9b819793a64463aed77c615d6cb226eea5487ccfc0783043a587254cda2b6f26", types.HashTypeType, "0xc76edf469816aa22f416503c38d0b533d2a018e253e379f134c3985b3472c842", 0, types.DepTypeCode),
+		},
+	}
+	// Testnet is the registry of the testnet deployments.
+	Testnet = &Registry{
+		scripts: map[Name]*Script{
+			Secp256k1Blake160: deployment("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8", types.HashTypeType, "0xf8de3bb47d055cdf460d93a2a6e1b05f7432f9777c8c474abf4eec1d4aee5d37", 0, types.DepTypeDepGroup),
+			Multisig:          deployment("0x5c5069eb0857efc65e1bca0c07df34c31663b3622fd3876c876320fc9634e2a8", types.HashTypeType, "0xf8de3bb47d055cdf460d93a2a6e1b05f7432f9777c8c474abf4eec1d4aee5d37", 1, types.DepTypeDepGroup),
+			DAO:               deployment("0x82d76d1b75fe2fd9a27dfbaa65a039221a380d76c926f378d3f81cf3e7e13f2e", types.HashTypeType, "0x8f8c79eb6671709633fe6a46de93c0fedc9c1b8a6527a18d3983879542635c9f", 2, types.DepTypeCode),
+			SUDT:              deployment("0xc5e5dcf215925f7ef4dfaf5f4b4f105bc321c02776d6e7d52a1db3fcd9d011a4", types.HashTypeType, "0xe12877ebd2c3c364dc46c5c992bcfaf4fee33fa13eebdf82c591fc9825aab769", 0, types.DepTypeCode),
+			XUDT:              deployment("0x25c29dc317811a6f6f3985a7a9ebc4838bd388d19d0feeecf0bcd60f6c0975bb", types.HashTypeType, "0xbf6fb538763efec2a70a6a3dcb7242787087e1030c4e7d86585bc63a9d337f5f", 0, types.DepTypeCode),
+			ACP:               deployment("0x3419a1c09eb2567f6552ee7a8ecffd64155cffe0f1796e6e61ec088d740c1356", types.HashTypeType, "0xec26b0f85ed839ece5f11c4c4e837ec359f5adc4420410f6453b1f6b60fb96a6", 0, types.DepTypeDepGroup),
+			Cheque:            deployment("0x60d5f39efce409c587cb9ea359cefdead650ca128f0bd9cb3855348f98c70d5b", types.HashTypeType, "0x7f96858be0a9d584b4a9ea190e0420835156a6010a5fde15ffcdc9d9c721ccab", 0, types.DepTypeDepGroup),
+			Omnilock:          deployment("0xf329effd1c475a2978453c8600e1eaf0bc2087ee093c3ee64cc96ec6847752cb", types.HashTypeType, "0xec18bf0d857c981c3d1f4e17999b9b90c484b303378e94de1a57b0872f5d4602", 0, types.DepTypeCode),
+		},
+	}
+)
+
+func deployment(codeHash string, hashType types.ScriptHashType, txHash string, index uint, depType types.DepType) *Script {
+	return &Script{
+		CodeHash: types.HexToHash(codeHash),
+		HashType: hashType,
+		CellDep: &types.CellDep{
+			OutPoint: &types.OutPoint{
+				TxHash: types.HexToHash(txHash),
+				Index:  index,
+			},
+			DepType: depType,
+		},
+	}
+}
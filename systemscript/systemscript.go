@@ -0,0 +1,109 @@
+// Package systemscript registers the code hash, hash type and cell dep of the well-known scripts of a network.
+package systemscript
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// Name names a well-known script.
+type Name string
+
+const (
+	Secp256k1Blake160 Name = "secp256k1_blake160_sighash_all"
+	Multisig          Name = "secp256k1_blake160_multisig_all"
+	DAO               Name = "dao"
+	SUDT              Name = "sudt"
+	XUDT              Name = "xudt"
+	ACP               Name = "anyone_can_pay"
+	Cheque            Name = "cheque"
+	Omnilock          Name = "omnilock"
+)
+
+// ErrNotRegistered is returned when the script is not registered for the network.
+var ErrNotRegistered = errors.New("script is not registered")
+
+// Script is a deployment of a script: its code hash and hash type, and the cell dep of its code.
+type Script struct {
+	CodeHash types.Hash
+	HashType types.ScriptHashType
+	CellDep  *types.CellDep
+}
+
+// Registry holds the deployments of the well-known scripts of a network.
+type Registry struct {
+	scripts map[Name]*Script
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		scripts: make(map[Name]*Script),
+	}
+}
+
+// Register adds or replaces the deployment of the script.
+func (r *Registry) Register(name Name, script *Script) {
+	r.scripts[name] = script
+}
+
+// Get returns the deployment of the script.
+func (r *Registry) Get(name Name) (*Script, error) {
+	script, ok := r.scripts[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotRegistered)
+	}
+	return script, nil
+}
+
+// MustGet returns the deployment of the script and panics when it is not registered. It is meant for the package
+// variables of the deployments of the built-in registries, whose missing entries are programming errors.
+func (r *Registry) MustGet(name Name) *Script {
+	script, err := r.Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
+// Script returns the script of the deployment with the args.
+func (r *Registry) Script(name Name, args []byte) (*types.Script, error) {
+	script, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Script{
+		CodeHash: script.CodeHash,
+		HashType: script.HashType,
+		Args:     args,
+	}, nil
+}
+
+// Matches reports whether the script runs the code of the deployment, whatever its args.
+func (r *Registry) Matches(name Name, script *types.Script) bool {
+	deployment, ok := r.scripts[name]
+	return ok && script != nil && script.CodeHash == deployment.CodeHash && script.HashType == deployment.HashType
+}
+
+// Find returns the name of the deployment the script runs the code of.
+func (r *Registry) Find(script *types.Script) (Name, bool) {
+	for name := range r.scripts {
+		if r.Matches(name, script) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ForNetwork returns the registry of the mainnet or the testnet.
+func ForNetwork(mode address.Mode) (*Registry, error) {
+	switch mode {
+	case address.Mainnet:
+		return Mainnet, nil
+	case address.Testnet:
+		return Testnet, nil
+	}
+	return nil, fmt.Errorf("no registry for network %s", mode)
+}
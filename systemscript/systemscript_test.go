@@ -0,0 +1,113 @@
+package systemscript
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+// genesisClient serves a genesis block, leaving the other methods unimplemented.
+type genesisClient struct {
+	rpc.Client
+	genesis *types.Block
+}
+
+func (c *genesisClient) GetBlockByNumber(ctx context.Context, number uint64) (*types.Block, error) {
+	return c.genesis, nil
+}
+
+// mainnetGenesis returns the system cells of the mainnet genesis block: the type ids of the secp256k1-blake160, DAO
+// and multisig cells and the two dep group cells.
+func mainnetGenesis() *types.Block {
+	typeID := func(args string) *types.Script {
+		return &types.Script{
+			CodeHash: types.HexToHash("0x00000000000000000000000000000000000000000000000000545950455f4944"),
+			HashType: types.HashTypeType,
+			Args:     common.FromHex(args),
+		}
+	}
+	cells := &types.Transaction{
+		Hash: types.HexToHash("0xe2fb199810d49a4d8beec56718ba2593b665db9d52299a0f9e6e75416d73ff5c"),
+		Outputs: []*types.CellOutput{
+			{},
+			{Type: typeID("0x8536c9d5d908bd89fc70099e4284870708b6632356aad98734fcf43f6f71c304")},
+			{Type: typeID("0xb2a8500929d6a1294bf9bf1bf565f549fa4a5f1316a3306ad3d4783e64bcf626")},
+			{},
+			{Type: typeID("0xd813c1b15bd79c8321ad7f5819e5d9f659a1042b72e64659a2c092be68ea9758")},
+		},
+	}
+	groups := &types.Transaction{
+		Hash:    types.HexToHash("0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c"),
+		Outputs: []*types.CellOutput{{}, {}},
+	}
+	return &types.Block{Transactions: []*types.Transaction{cells, groups}}
+}
+
+func TestFromGenesis(t *testing.T) {
+	r, err := FromGenesis(context.Background(), &genesisClient{genesis: mainnetGenesis()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []Name{Secp256k1Blake160, DAO, Multisig} {
+		got, want := r.MustGet(name), Mainnet.MustGet(name)
+		if got.CodeHash != want.CodeHash || got.HashType != want.HashType || *got.CellDep.OutPoint != *want.CellDep.OutPoint || got.CellDep.DepType != want.CellDep.DepType {
+			t.Errorf("%s discovered from the genesis block is %+v, want the mainnet deployment", name, got)
+		}
+	}
+	if _, err := r.Get(SUDT); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("genesis registry returned sUDT with %v", err)
+	}
+
+	short := mainnetGenesis()
+	short.Transactions = short.Transactions[:1]
+	if _, err := FromGenesis(context.Background(), &genesisClient{genesis: short}); err == nil {
+		t.Error("discovered the system scripts of a genesis block without dep groups")
+	}
+	untyped := mainnetGenesis()
+	untyped.Transactions[0].Outputs[2].Type = nil
+	if _, err := FromGenesis(context.Background(), &genesisClient{genesis: untyped}); err == nil {
+		t.Error("discovered the DAO of a genesis cell without type id")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	for _, r := range []*Registry{Mainnet, Testnet} {
+		for _, name := range []Name{Secp256k1Blake160, Multisig, DAO, SUDT, XUDT, ACP, Cheque, Omnilock} {
+			script, err := r.Script(name, []byte{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found, ok := r.Find(script); !ok || found != name {
+				t.Errorf("script of %s is found as %s", name, found)
+			}
+		}
+	}
+
+	r := NewRegistry()
+	if _, ok := r.Find(&types.Script{}); ok {
+		t.Error("empty registry found a script")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustGet of an unregistered script did not panic")
+		}
+	}()
+	r.MustGet(ACP)
+}
+
+func TestForNetwork(t *testing.T) {
+	if r, err := ForNetwork(address.Mainnet); err != nil || r != Mainnet {
+		t.Errorf("mainnet registry is %v, %v", r, err)
+	}
+	if r, err := ForNetwork(address.Testnet); err != nil || r != Testnet {
+		t.Errorf("testnet registry is %v, %v", r, err)
+	}
+	if _, err := ForNetwork(address.Mode("dev")); err == nil {
+		t.Error("returned a registry of an unknown network")
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/nervosnetwork/ckb-sdk-go/types"
 	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
 	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
 )
 
 func main() {
@@ -24,12 +24,9 @@ func main() {
 
 	fmt.Println("-------------------------- Get Cells Capacity ------------------------------")
 	args, _ := hex.DecodeString("c2baa1d5b45a3ad6452b9c98ad8e2cc52e5123c7")
+	lock, _ := systemscript.Mainnet.Script(systemscript.Secp256k1Blake160, args)
 	searchKey := &indexer.SearchKey{
-		Script: &types.Script{
-			CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
-			HashType: types.HashTypeType,
-			Args:     args,
-		},
+		Script:     lock,
 		ScriptType: indexer.ScriptTypeLock,
 	}
 
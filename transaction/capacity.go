@@ -0,0 +1,57 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// ShannonsPerByte is the capacity a byte of a cell occupies, one CKB.
+const ShannonsPerByte = 100000000
+
+// InsufficientCapacityError reports an output holding less capacity than it occupies.
+type InsufficientCapacityError struct {
+	Index    int
+	Capacity uint64
+	Occupied uint64
+}
+
+func (e *InsufficientCapacityError) Error() string {
+	return fmt.Sprintf("output %d holds %d shannons but occupies %d", e.Index, e.Capacity, e.Occupied)
+}
+
+// OccupiedCapacity returns the capacity the cell occupies: 8 bytes of capacity, the lock and type scripts each of
+// a 32 bytes code hash, a hash type byte and the args, and the data.
+func OccupiedCapacity(output *types.CellOutput, data []byte) uint64 {
+	size := 8 + scriptSize(output.Lock) + len(data)
+	if output.Type != nil {
+		size += scriptSize(output.Type)
+	}
+	return uint64(size) * ShannonsPerByte
+}
+
+// ValidateOutputs checks that every output has its data and holds at least the capacity it occupies, returning an
+// InsufficientCapacityError for the first output which does not.
+func ValidateOutputs(tx *types.Transaction) error {
+	if len(tx.OutputsData) != len(tx.Outputs) {
+		return fmt.Errorf("transaction has %d outputs but %d outputs data", len(tx.Outputs), len(tx.OutputsData))
+	}
+	for i, output := range tx.Outputs {
+		if output.Lock == nil {
+			return fmt.Errorf("output %d has no lock", i)
+		}
+		occupied := OccupiedCapacity(output, tx.OutputsData[i])
+		if output.Capacity < occupied {
+			return &InsufficientCapacityError{
+				Index:    i,
+				Capacity: output.Capacity,
+				Occupied: occupied,
+			}
+		}
+	}
+	return nil
+}
+
+func scriptSize(script *types.Script) int {
+	return types.HashLength + 1 + len(script.Args)
+}
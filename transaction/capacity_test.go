@@ -0,0 +1,57 @@
+package transaction
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+func TestOccupiedCapacity(t *testing.T) {
+	typeScript := func(args int) *types.Script {
+		return &types.Script{
+			CodeHash: types.HexToHash("0x5e7a36a77e68eecc013dfa2fe6a23f3b6c344b04005808694ae6dd45eea4cfd5"),
+			HashType: types.HashTypeType,
+			Args:     make([]byte, args),
+		}
+	}
+	tests := []struct {
+		name     string
+		output   *types.CellOutput
+		data     []byte
+		occupied uint64
+	}{
+		{"secp256k1-blake160 cell", &types.CellOutput{Lock: secpLock()}, nil, 61},
+		{"DAO deposit", &types.CellOutput{Lock: secpLock(), Type: typeScript(0)}, make([]byte, 8), 102},
+		{"sUDT cell", &types.CellOutput{Lock: secpLock(), Type: typeScript(32)}, make([]byte, 16), 142},
+	}
+	for _, tt := range tests {
+		if occupied := OccupiedCapacity(tt.output, tt.data); occupied != tt.occupied*ShannonsPerByte {
+			t.Errorf("%s occupies %d shannons, want %d CKB", tt.name, occupied, tt.occupied)
+		}
+	}
+}
+
+func TestValidateOutputs(t *testing.T) {
+	tx := transfer()
+	if err := ValidateOutputs(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	tx.Outputs[1].Capacity = 61*ShannonsPerByte - 1
+	var insufficient *InsufficientCapacityError
+	if err := ValidateOutputs(tx); !errors.As(err, &insufficient) || insufficient.Index != 1 || insufficient.Occupied != 61*ShannonsPerByte {
+		t.Errorf("validating an output below its occupied capacity returned %v", err)
+	}
+
+	tx = transfer()
+	tx.OutputsData = tx.OutputsData[:1]
+	if err := ValidateOutputs(tx); err == nil {
+		t.Error("validated an output without data")
+	}
+	tx = transfer()
+	tx.Outputs[0].Lock = nil
+	if err := ValidateOutputs(tx); err == nil {
+		t.Error("validated an output without lock")
+	}
+}
@@ -0,0 +1,86 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+)
+
+// blockOffsetSize is the size of the offset of a transaction in the transactions of a block.
+const blockOffsetSize = 4
+
+// Size returns the size of the transaction in a block: its serialization and its 4 bytes offset.
+func Size(tx *types.Transaction) (uint64, error) {
+	data, err := molecule.SerializeTransaction(tx)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(data)) + blockOffsetSize, nil
+}
+
+// Fee returns the minimum fee of size bytes at feeRate shannons per KB, rounded up.
+func Fee(size uint64, feeRate uint64) uint64 {
+	fee := size * feeRate / 1000
+	if fee*1000 < size*feeRate {
+		fee++
+	}
+	return fee
+}
+
+// MinimumFee returns the minimum fee of the transaction at feeRate shannons per KB.
+func MinimumFee(tx *types.Transaction, feeRate uint64) (uint64, error) {
+	size, err := Size(tx)
+	if err != nil {
+		return 0, err
+	}
+	return Fee(size, feeRate), nil
+}
+
+// FillPlaceholders sets the lock of the first witness of every group to lockSizes zero bytes, the size of the lock
+// once the group is signed, and keeps the input and output types of the witness.
+func FillPlaceholders(tx *types.Transaction, groups []*ScriptGroup, lockSizes []int) error {
+	if len(lockSizes) != len(groups) {
+		return fmt.Errorf("%d lock sizes for %d script groups", len(lockSizes), len(groups))
+	}
+	for i, group := range groups {
+		if len(group.InputIndices) == 0 {
+			return fmt.Errorf("empty script group")
+		}
+		index := group.InputIndices[0]
+		if index >= len(tx.Witnesses) {
+			return fmt.Errorf("transaction has no witness %d", index)
+		}
+		witnessArgs := &types.WitnessArgs{}
+		if len(tx.Witnesses[index]) > 0 {
+			var err error
+			witnessArgs, err = molecule.DeserializeWitnessArgs(tx.Witnesses[index])
+			if err != nil {
+				return err
+			}
+		}
+		witnessArgs.Lock = make([]byte, lockSizes[i])
+		tx.Witnesses[index] = molecule.SerializeWitnessArgs(witnessArgs)
+	}
+	return nil
+}
+
+// EstimateFee returns the minimum fee of the unsigned transaction once its groups are signed with locks of lockSizes
+// bytes, leaving the transaction unchanged.
+func EstimateFee(tx *types.Transaction, groups []*ScriptGroup, lockSizes []int, feeRate uint64) (uint64, error) {
+	estimated := *tx
+	count := len(tx.Witnesses)
+	if count < len(tx.Inputs) {
+		count = len(tx.Inputs)
+	}
+	estimated.Witnesses = make([][]byte, count)
+	for i := range estimated.Witnesses {
+		estimated.Witnesses[i] = []byte{}
+	}
+	copy(estimated.Witnesses, tx.Witnesses)
+	err := FillPlaceholders(&estimated, groups, lockSizes)
+	if err != nil {
+		return 0, err
+	}
+	return MinimumFee(&estimated, feeRate)
+}
@@ -0,0 +1,117 @@
+package transaction
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+)
+
+func secpLock() *types.Script {
+	return &types.Script{
+		CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
+		HashType: types.HashTypeType,
+		Args:     common.FromHex("0xedcda9513fa030ce4308e29245a22c022d0443bb"),
+	}
+}
+
+// transfer returns an unsigned secp256k1-blake160 transfer of one input to a recipient and a change output.
+func transfer() *types.Transaction {
+	return &types.Transaction{
+		CellDeps: []*types.CellDep{{
+			OutPoint: &types.OutPoint{TxHash: types.HexToHash("0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c")},
+			DepType:  types.DepTypeDepGroup,
+		}},
+		HeaderDeps: []types.Hash{},
+		Inputs: []*types.CellInput{{
+			PreviousOutput: &types.OutPoint{TxHash: types.HexToHash("0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541")},
+		}},
+		Outputs:     []*types.CellOutput{{Capacity: 10000000000, Lock: secpLock()}, {Capacity: 89999999536, Lock: secpLock()}},
+		OutputsData: [][]byte{{}, {}},
+		Witnesses:   [][]byte{{}},
+	}
+}
+
+func TestSize(t *testing.T) {
+	empty, err := Size(&types.Transaction{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a table of the 52 bytes raw transaction and an empty witness vector, with the block offset.
+	if empty != 12+52+4+4 {
+		t.Errorf("size of an empty transaction is %d, want 72", empty)
+	}
+
+	// the signed one input two outputs transfer is the well-known 464 bytes transaction.
+	tx := transfer()
+	tx.Witnesses[0] = molecule.SerializeWitnessArgs(&types.WitnessArgs{Lock: make([]byte, 65)})
+	size, err := Size(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 464 {
+		t.Errorf("size of a signed transfer is %d, want 464", size)
+	}
+}
+
+func TestFee(t *testing.T) {
+	tests := []struct {
+		size, feeRate, fee uint64
+	}{
+		{464, 1000, 464},
+		{1000, 1000, 1000},
+		{464, 1, 1},
+		{999, 1, 1},
+		{1001, 1, 2},
+		{0, 1000, 0},
+		{464, 0, 0},
+	}
+	for _, tt := range tests {
+		if fee := Fee(tt.size, tt.feeRate); fee != tt.fee {
+			t.Errorf("fee of %d bytes at %d is %d, want %d", tt.size, tt.feeRate, fee, tt.fee)
+		}
+	}
+}
+
+func TestFillPlaceholders(t *testing.T) {
+	tx := transfer()
+	inputType := []byte{1, 2, 3}
+	tx.Witnesses[0] = molecule.SerializeWitnessArgs(&types.WitnessArgs{Lock: []byte{4}, InputType: inputType})
+	groups := []*ScriptGroup{{Script: secpLock(), InputIndices: []int{0}}}
+	if err := FillPlaceholders(tx, groups, []int{65}); err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, err := molecule.DeserializeWitnessArgs(tx.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(witnessArgs.Lock, make([]byte, 65)) || !bytes.Equal(witnessArgs.InputType, inputType) {
+		t.Errorf("placeholder witness args are %+v", witnessArgs)
+	}
+
+	if err := FillPlaceholders(tx, groups, nil); err == nil {
+		t.Error("filled placeholders without lock sizes")
+	}
+	missing := []*ScriptGroup{{Script: secpLock(), InputIndices: []int{1}}}
+	if err := FillPlaceholders(tx, missing, []int{65}); err == nil {
+		t.Error("filled the placeholder of a missing witness")
+	}
+}
+
+func TestEstimateFee(t *testing.T) {
+	tx := transfer()
+	tx.Witnesses = nil
+	groups := []*ScriptGroup{{Script: secpLock(), InputIndices: []int{0}}}
+	fee, err := EstimateFee(tx, groups, []int{65}, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee != 464 {
+		t.Errorf("estimated fee is %d, want 464", fee)
+	}
+	if tx.Witnesses != nil {
+		t.Error("estimating the fee changed the transaction")
+	}
+}
@@ -0,0 +1,34 @@
+package transaction
+
+import (
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// ScriptGroup is the set of inputs locked by the same script, which are unlocked by a single signature.
+type ScriptGroup struct {
+	Script *types.Script `json:"script"`
+	// InputIndices are the indices of the group inputs in the transaction, the first one carries the group witness.
+	InputIndices []int `json:"input_indices"`
+}
+
+// GroupInputs groups the inputs by lock script, in the order each lock script first appears.
+func GroupInputs(locks []*types.Script) ([]*ScriptGroup, error) {
+	var groups []*ScriptGroup
+	index := make(map[types.Hash]*ScriptGroup)
+	for i, lock := range locks {
+		hash, err := lock.Hash()
+		if err != nil {
+			return nil, err
+		}
+		group, ok := index[hash]
+		if !ok {
+			group = &ScriptGroup{
+				Script: lock,
+			}
+			index[hash] = group
+			groups = append(groups, group)
+		}
+		group.InputIndices = append(group.InputIndices, i)
+	}
+	return groups, nil
+}
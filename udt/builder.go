@@ -0,0 +1,167 @@
+package udt
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/builder"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+// Builder builds unsigned sUDT and xUDT transactions of secp256k1-blake160 addresses. Token cells hold their occupied
+// capacity, paid by the sender CKB cells.
+type Builder struct {
+	client rpc.Client
+	config *Config
+	// FeeRate is the fee rate in shannons per KB.
+	FeeRate uint64
+	// CellDeps are the cell deps of the scripts the token cells run besides the token type script, such as xUDT extension scripts.
+	CellDeps []*types.CellDep
+}
+
+func NewBuilder(client rpc.Client, config *Config) *Builder {
+	return &Builder{
+		client:  client,
+		config:  config,
+		FeeRate: builder.DefaultFeeRate,
+	}
+}
+
+// Issue mints amount of the token of the owner address to the recipient address. The owner pays the token cell
+// capacity and the fee, and its input unlocks the owner mode of the type script.
+func (b *Builder) Issue(ctx context.Context, owner string, recipient string, amount *big.Int) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	parsedOwner, err := address.Parse(owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := b.config.TypeScript(parsedOwner.Script)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := b.transferBuilder()
+	err = t.AddSender(owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = b.addTokenOutput(t, recipient, token, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t.Build(ctx)
+}
+
+// Transfer sends amount of the token from the sender address to the recipient address. The token left in the
+// spent token cells returns to the sender in a new token cell, the sender CKB cells pay the capacity and the fee.
+// The extension scripts of xUDT tokens holding their hash in the args are carried over to the witness from the
+// transaction which created the first spent token cell.
+func (b *Builder) Transfer(ctx context.Context, from string, to string, token *types.Script, amount *big.Int) (*types.Transaction, []*transaction.ScriptGroup, error) {
+	if !b.config.Matches(token) {
+		return nil, nil, errors.New("token is not a type script of the deployment")
+	}
+	parsedFrom, err := address.Parse(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	cells, err := Cells(ctx, b.client, parsedFrom.Script, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs, change, err := SelectAmount(cells, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := b.transferBuilder()
+	err = t.AddSender(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputType, err := b.inputType(ctx, token, inputs[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	t.AddInputWithType(inputs[0], inputType)
+	for _, cell := range inputs[1:] {
+		t.AddInput(cell)
+	}
+	err = b.addTokenOutput(t, to, token, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if change.Sign() > 0 {
+		err = b.addTokenOutput(t, from, token, change)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return t.Build(ctx)
+}
+
+func (b *Builder) transferBuilder() *builder.TransferBuilder {
+	t := builder.NewTransferBuilder(b.client)
+	t.FeeRate = b.FeeRate
+	t.AddCellDep(b.config.CellDep)
+	for _, dep := range b.CellDeps {
+		t.AddCellDep(dep)
+	}
+	return t
+}
+
+// inputType returns the witness input type of the token group: when the args hold the extension scripts hash, a new
+// xUDT witness of the extension scripts only.
+func (b *Builder) inputType(ctx context.Context, token *types.Script, cell *indexer.LiveCell) ([]byte, error) {
+	if !b.config.XUDT {
+		return nil, nil
+	}
+	args, err := ParseXUDTArgs(token.Args)
+	if err != nil {
+		return nil, err
+	}
+	if args.Flags&xudtExtensionMask != XUDTFlagExtensionHash {
+		return nil, nil
+	}
+	witness, err := FindXUDTWitness(ctx, b.client, cell, args.ExtensionHash)
+	if err != nil {
+		return nil, err
+	}
+	return witness.Serialize()
+}
+
+func (b *Builder) addTokenOutput(t *builder.TransferBuilder, addr string, token *types.Script, amount *big.Int) error {
+	parsed, err := address.Parse(addr)
+	if err != nil {
+		return err
+	}
+	data, err := EncodeAmount(amount)
+	if err != nil {
+		return err
+	}
+	t.AddOutput(&types.CellOutput{
+		Capacity: OccupiedCapacity(parsed.Script, token),
+		Lock:     parsed.Script,
+		Type:     token,
+	}, data)
+	return nil
+}
+
+// SelectAmount selects token cells in order until they hold amount, and returns them with the amount left over.
+func SelectAmount(cells []*indexer.LiveCell, amount *big.Int) ([]*indexer.LiveCell, *big.Int, error) {
+	total := new(big.Int)
+	for i, cell := range cells {
+		value, err := ParseAmount(cell.OutputData)
+		if err != nil {
+			return nil, nil, err
+		}
+		total.Add(total, value)
+		if total.Cmp(amount) >= 0 {
+			return cells[:i+1], total.Sub(total, amount), nil
+		}
+	}
+	return nil, nil, ErrInsufficientAmount
+}
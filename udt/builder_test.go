@@ -0,0 +1,211 @@
+package udt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+)
+
+const (
+	ownerAddress    = "ckb1qyqwmndf2yl6qvxwgvyw9yj95gkqytgygwasshh9m8"
+	receiverAddress = "ckb1qyqvsv5240xeh85wvnau2eky8pwrhh4jr8ts6f6daz"
+	tokenTx         = "0xa563884b3686078ec7e7677a5f86449b15cf2693f3c1241766c6996f206cc541"
+)
+
+// chainClient serves the live cells of the search key lock, the transactions by hash and the mainnet genesis
+// system cells, leaving the other methods unimplemented.
+type chainClient struct {
+	rpc.Client
+	cells        []*indexer.LiveCell
+	transactions map[types.Hash]*types.Transaction
+}
+
+func (c *chainClient) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	var cells []*indexer.LiveCell
+	for _, cell := range c.cells {
+		if cell.Output.Lock.CodeHash == searchKey.Script.CodeHash && bytes.Equal(cell.Output.Lock.Args, searchKey.Script.Args) {
+			cells = append(cells, cell)
+		}
+	}
+	return &indexer.LiveCells{Objects: cells}, nil
+}
+
+func (c *chainClient) GetTransaction(ctx context.Context, hash types.Hash) (*types.TransactionWithStatus, error) {
+	tx, ok := c.transactions[hash]
+	if !ok {
+		return nil, errors.New("transaction not found")
+	}
+	return &types.TransactionWithStatus{Transaction: tx}, nil
+}
+
+func (c *chainClient) GetBlockByNumber(ctx context.Context, number uint64) (*types.Block, error) {
+	typeID := func(args string) *types.Script {
+		return &types.Script{
+			CodeHash: types.HexToHash("0x00000000000000000000000000000000000000000000000000545950455f4944"),
+			HashType: types.HashTypeType,
+			Args:     common.FromHex(args),
+		}
+	}
+	return &types.Block{Transactions: []*types.Transaction{
+		{
+			Hash: types.HexToHash("0xe2fb199810d49a4d8beec56718ba2593b665db9d52299a0f9e6e75416d73ff5c"),
+			Outputs: []*types.CellOutput{
+				{},
+				{Type: typeID("0x8536c9d5d908bd89fc70099e4284870708b6632356aad98734fcf43f6f71c304")},
+				{Type: typeID("0xb2a8500929d6a1294bf9bf1bf565f549fa4a5f1316a3306ad3d4783e64bcf626")},
+				{},
+				{Type: typeID("0xd813c1b15bd79c8321ad7f5819e5d9f659a1042b72e64659a2c092be68ea9758")},
+			},
+		},
+		{
+			Hash:    types.HexToHash("0x71a7ba8fc96349fea0ed3a5c47992e3b4084b031a42264a018e0072e8172e46c"),
+			Outputs: []*types.CellOutput{{}, {}},
+		},
+	}}, nil
+}
+
+func parse(t *testing.T, addr string) *types.Script {
+	parsed, err := address.Parse(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Script
+}
+
+func tokenCell(t *testing.T, index uint, lock *types.Script, token *types.Script, amount int64) *indexer.LiveCell {
+	data, err := EncodeAmount(big.NewInt(amount))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &indexer.LiveCell{
+		OutPoint:   &types.OutPoint{TxHash: types.HexToHash(tokenTx), Index: index},
+		Output:     &types.CellOutput{Capacity: OccupiedCapacity(lock, token), Lock: lock, Type: token},
+		OutputData: data,
+	}
+}
+
+// testChain returns the sUDT token issued by the owner and a client serving two token cells of 10 and 20 tokens of
+// the owner, a cell of another token and a 1000 CKB cell of the owner.
+func testChain(t *testing.T) (*types.Script, *chainClient) {
+	owner := parse(t, ownerAddress)
+	token, err := MainnetSUDT.TypeScript(owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := &types.Script{CodeHash: token.CodeHash, HashType: token.HashType, Args: make([]byte, 32)}
+	return token, &chainClient{cells: []*indexer.LiveCell{
+		tokenCell(t, 0, owner, token, 10),
+		tokenCell(t, 1, owner, other, 5),
+		tokenCell(t, 2, owner, token, 20),
+		{
+			OutPoint:   &types.OutPoint{TxHash: types.HexToHash(tokenTx), Index: 3},
+			Output:     &types.CellOutput{Capacity: 1000 * 100000000, Lock: owner},
+			OutputData: []byte{},
+		},
+	}}
+}
+
+func TestBalances(t *testing.T) {
+	token, client := testChain(t)
+	owner := parse(t, ownerAddress)
+	balance, err := GetBalance(context.Background(), client, owner, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Int64() != 30 {
+		t.Errorf("balance is %s", balance)
+	}
+	balances, err := GetBalances(context.Background(), client, owner, MainnetSUDT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := molecule.ScriptHash(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 2 || balances[hash].Int64() != 30 {
+		t.Errorf("balances are %v", balances)
+	}
+}
+
+func TestSelectAmount(t *testing.T) {
+	token, client := testChain(t)
+	cells, err := Cells(context.Background(), client, parse(t, ownerAddress), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected, change, err := SelectAmount(cells, big.NewInt(15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 2 || change.Int64() != 15 {
+		t.Errorf("selected %d cells with change %s", len(selected), change)
+	}
+	if _, _, err := SelectAmount(cells, big.NewInt(31)); !errors.Is(err, ErrInsufficientAmount) {
+		t.Errorf("selected above the balance with %v", err)
+	}
+}
+
+func TestIssue(t *testing.T) {
+	token, client := testChain(t)
+	tx, groups, err := NewBuilder(client, MainnetSUDT).Issue(context.Background(), ownerAddress, receiverAddress, big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver := parse(t, receiverAddress)
+	output := tx.Outputs[0]
+	amount, err := ParseAmount(tx.OutputsData[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Capacity != 142*100000000 || !sameScript(token, output.Type) || !bytes.Equal(output.Lock.Args, receiver.Args) || amount.Int64() != 1000 {
+		t.Errorf("issued %s tokens in %+v", amount, output)
+	}
+	// the owner mode needs an input of the owner lock, the CKB cell of the owner.
+	if len(tx.Inputs) != 1 || tx.Inputs[0].PreviousOutput.Index != 3 || len(groups) != 1 {
+		t.Errorf("issue spends %d inputs in %d groups", len(tx.Inputs), len(groups))
+	}
+	if *tx.CellDeps[1].OutPoint != *MainnetSUDT.CellDep.OutPoint {
+		t.Errorf("got cell dep %s#%d, want the sUDT code", tx.CellDeps[1].OutPoint.TxHash.String(), tx.CellDeps[1].OutPoint.Index)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	token, client := testChain(t)
+	b := NewBuilder(client, MainnetSUDT)
+	tx, _, err := b.Transfer(context.Background(), ownerAddress, receiverAddress, token, big.NewInt(25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Inputs) != 3 || tx.Inputs[0].PreviousOutput.Index != 0 || tx.Inputs[1].PreviousOutput.Index != 2 || tx.Inputs[2].PreviousOutput.Index != 3 {
+		t.Fatalf("transfer spends %d inputs", len(tx.Inputs))
+	}
+	sent, err := ParseAmount(tx.OutputsData[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := ParseAmount(tx.OutputsData[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := parse(t, ownerAddress)
+	if sent.Int64() != 25 || change.Int64() != 5 || !bytes.Equal(tx.Outputs[1].Lock.Args, owner.Args) || !sameScript(token, tx.Outputs[1].Type) {
+		t.Errorf("sent %s tokens with change %s", sent, change)
+	}
+
+	if _, _, err := b.Transfer(context.Background(), ownerAddress, receiverAddress, token, big.NewInt(31)); !errors.Is(err, ErrInsufficientAmount) {
+		t.Errorf("transferred above the balance with %v", err)
+	}
+	if _, _, err := b.Transfer(context.Background(), ownerAddress, receiverAddress, owner, big.NewInt(1)); err == nil {
+		t.Error("transferred a token of another type script")
+	}
+}
@@ -0,0 +1,204 @@
+// Package udt queries and builds transfers of user defined tokens.
+package udt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/collector"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+	"github.com/shaojunda/ckb-rich-sdk-go/transaction"
+)
+
+// AmountLength is the length of the little-endian uint128 amount at the head of a token cell data.
+const AmountLength = 16
+
+// ErrInsufficientAmount is returned when the token cells do not hold the amount to send.
+var ErrInsufficientAmount = errors.New("insufficient token amount")
+
+var maxAmount = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// Config is a deployment of a token type script.
+type Config struct {
+	CodeHash types.Hash
+	HashType types.ScriptHashType
+	CellDep  *types.CellDep
+	// XUDT reports whether the type script is xUDT, whose args may carry extension scripts.
+	XUDT bool
+}
+
+var (
+	MainnetSUDT = configOf(systemscript.Mainnet.MustGet(systemscript.SUDT), false)
+	TestnetSUDT = configOf(systemscript.Testnet.MustGet(systemscript.SUDT), false)
+)
+
+// NewSUDTConfig returns the sUDT deployment of the registry, such as a devnet registry it is registered in.
+func NewSUDTConfig(registry *systemscript.Registry) (*Config, error) {
+	return newConfig(registry, systemscript.SUDT, false)
+}
+
+// SUDTConfig returns the sUDT deployment of the network.
+func SUDTConfig(mode address.Mode) (*Config, error) {
+	registry, err := systemscript.ForNetwork(mode)
+	if err != nil {
+		return nil, err
+	}
+	return NewSUDTConfig(registry)
+}
+
+func newConfig(registry *systemscript.Registry, name systemscript.Name, xudt bool) (*Config, error) {
+	script, err := registry.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return configOf(script, xudt), nil
+}
+
+func configOf(script *systemscript.Script, xudt bool) *Config {
+	return &Config{
+		CodeHash: script.CodeHash,
+		HashType: script.HashType,
+		CellDep:  script.CellDep,
+		XUDT:     xudt,
+	}
+}
+
+// TypeScript returns the sUDT type script of the token issued by the owner lock.
+func (c *Config) TypeScript(owner *types.Script) (*types.Script, error) {
+	hash, err := owner.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return &types.Script{
+		CodeHash: c.CodeHash,
+		HashType: c.HashType,
+		Args:     hash.Bytes(),
+	}, nil
+}
+
+// Matches reports whether the script is a type script of the deployment.
+func (c *Config) Matches(script *types.Script) bool {
+	return script != nil && script.CodeHash == c.CodeHash && script.HashType == c.HashType
+}
+
+// SearchKey returns the search key of all cells of the token.
+func SearchKey(token *types.Script) *indexer.SearchKey {
+	return &indexer.SearchKey{
+		Script:     token,
+		ScriptType: indexer.ScriptTypeType,
+	}
+}
+
+// ParseAmount decodes the amount at the head of a token cell data.
+func ParseAmount(data []byte) (*big.Int, error) {
+	if len(data) < AmountLength {
+		return nil, fmt.Errorf("token cell data is %d bytes, want at least %d", len(data), AmountLength)
+	}
+	b := make([]byte, AmountLength)
+	for i := range b {
+		b[i] = data[AmountLength-1-i]
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// EncodeAmount encodes the amount as a little-endian uint128.
+func EncodeAmount(amount *big.Int) ([]byte, error) {
+	if amount.Sign() < 0 || amount.Cmp(maxAmount) > 0 {
+		return nil, fmt.Errorf("amount %s is out of uint128 range", amount.String())
+	}
+	be := amount.Bytes()
+	b := make([]byte, AmountLength)
+	for i, v := range be {
+		b[len(be)-1-i] = v
+	}
+	return b, nil
+}
+
+// Cells returns the token cells of the lock.
+func Cells(ctx context.Context, source collector.CellSource, lock *types.Script, token *types.Script) ([]*indexer.LiveCell, error) {
+	c := collector.NewCellCollector(source, &indexer.SearchKey{
+		Script:     lock,
+		ScriptType: indexer.ScriptTypeLock,
+	}, nil)
+	c.AllowTypeScript = true
+	c.AllowData = true
+	c.Skip = func(cell *indexer.LiveCell) bool {
+		return !sameScript(token, cell.Output.Type) || len(cell.OutputData) < AmountLength
+	}
+	return c.Candidates(ctx)
+}
+
+// GetBalance returns the token amount held by the lock.
+func GetBalance(ctx context.Context, source collector.CellSource, lock *types.Script, token *types.Script) (*big.Int, error) {
+	cells, err := Cells(ctx, source, lock, token)
+	if err != nil {
+		return nil, err
+	}
+	return sumAmounts(cells)
+}
+
+// GetBalances returns the amount of every token of the deployment held by the lock, keyed by type script hash.
+func GetBalances(ctx context.Context, source collector.CellSource, lock *types.Script, config *Config) (map[types.Hash]*big.Int, error) {
+	c := collector.NewCellCollector(source, &indexer.SearchKey{
+		Script:     lock,
+		ScriptType: indexer.ScriptTypeLock,
+	}, nil)
+	c.AllowTypeScript = true
+	c.AllowData = true
+	c.Skip = func(cell *indexer.LiveCell) bool {
+		return !config.Matches(cell.Output.Type) || len(cell.OutputData) < AmountLength
+	}
+	cells, err := c.Candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[types.Hash]*big.Int)
+	for _, cell := range cells {
+		hash, err := molecule.ScriptHash(cell.Output.Type)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := ParseAmount(cell.OutputData)
+		if err != nil {
+			return nil, err
+		}
+		if balances[hash] == nil {
+			balances[hash] = new(big.Int)
+		}
+		balances[hash].Add(balances[hash], amount)
+	}
+	return balances, nil
+}
+
+func sumAmounts(cells []*indexer.LiveCell) (*big.Int, error) {
+	total := new(big.Int)
+	for _, cell := range cells {
+		amount, err := ParseAmount(cell.OutputData)
+		if err != nil {
+			return nil, err
+		}
+		total.Add(total, amount)
+	}
+	return total, nil
+}
+
+// sameScript compares the script fields, upstream Script.Equals cannot hash the data1 hash type.
+func sameScript(a *types.Script, b *types.Script) bool {
+	return b != nil && a.CodeHash == b.CodeHash && a.HashType == b.HashType && bytes.Equal(a.Args, b.Args)
+}
+
+// OccupiedCapacity returns the capacity of a token cell of the lock holding only the amount.
+func OccupiedCapacity(lock *types.Script, token *types.Script) uint64 {
+	return transaction.OccupiedCapacity(&types.CellOutput{
+		Lock: lock,
+		Type: token,
+	}, make([]byte, AmountLength))
+}
@@ -0,0 +1,46 @@
+package udt
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestEncodeAmount(t *testing.T) {
+	tests := []struct {
+		amount string
+		data   string
+	}{
+		{"0", "00000000000000000000000000000000"},
+		{"1", "01000000000000000000000000000000"},
+		{"100000000000000", "00407a10f35a00000000000000000000"},
+		{"340282366920938463463374607431768211455", "ffffffffffffffffffffffffffffffff"},
+	}
+	for _, tt := range tests {
+		amount, _ := new(big.Int).SetString(tt.amount, 10)
+		data, err := EncodeAmount(amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(data) != tt.data {
+			t.Errorf("amount %s encodes to %x, want %s", tt.amount, data, tt.data)
+		}
+		parsed, err := ParseAmount(append(data, 0xff))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Cmp(amount) != 0 {
+			t.Errorf("%x parses to %s, want %s", data, parsed, tt.amount)
+		}
+	}
+
+	if _, err := EncodeAmount(new(big.Int).Lsh(big.NewInt(1), 128)); err == nil {
+		t.Error("encoded an amount above uint128")
+	}
+	if _, err := EncodeAmount(big.NewInt(-1)); err == nil {
+		t.Error("encoded a negative amount")
+	}
+	if _, err := ParseAmount(make([]byte, AmountLength-1)); err == nil {
+		t.Error("parsed a short cell data")
+	}
+}
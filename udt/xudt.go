@@ -0,0 +1,244 @@
+package udt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+	"github.com/shaojunda/ckb-rich-sdk-go/rpc"
+	"github.com/shaojunda/ckb-rich-sdk-go/systemscript"
+)
+
+const (
+	// XUDTFlagExtensionScripts marks args whose extension data is the ScriptVec of the extension scripts.
+	XUDTFlagExtensionScripts = 0x1
+	// XUDTFlagExtensionHash marks args whose extension data is the blake160 hash of the ScriptVec of the
+	// extension scripts, the ScriptVec itself is in the witness of the transactions.
+	XUDTFlagExtensionHash = 0x2
+	// XUDTFlagOwnerModeInputType enables the owner mode when an input type script has the owner lock hash.
+	XUDTFlagOwnerModeInputType = 0x80000000
+	// XUDTFlagOwnerModeOutputType enables the owner mode when an output type script has the owner lock hash.
+	XUDTFlagOwnerModeOutputType = 0x40000000
+	// XUDTFlagOwnerModeUpdate disables the owner mode by an input lock with the owner lock hash.
+	XUDTFlagOwnerModeUpdate = 0x20000000
+
+	xudtExtensionMask = 0x1fffffff
+	flagsLength       = 4
+	extensionHashSize = 20
+)
+
+var (
+	MainnetXUDT = configOf(systemscript.Mainnet.MustGet(systemscript.XUDT), true)
+	TestnetXUDT = configOf(systemscript.Testnet.MustGet(systemscript.XUDT), true)
+)
+
+// NewXUDTConfig returns the xUDT deployment of the registry, such as a devnet registry it is registered in.
+func NewXUDTConfig(registry *systemscript.Registry) (*Config, error) {
+	return newConfig(registry, systemscript.XUDT, true)
+}
+
+// XUDTConfig returns the xUDT deployment of the network.
+func XUDTConfig(mode address.Mode) (*Config, error) {
+	registry, err := systemscript.ForNetwork(mode)
+	if err != nil {
+		return nil, err
+	}
+	return NewXUDTConfig(registry)
+}
+
+// XUDTArgs are the args of an xUDT type script: the owner lock hash, the flags and the extension data.
+type XUDTArgs struct {
+	OwnerLockHash types.Hash
+	Flags         uint32
+	// ExtensionScripts are the extension scripts of XUDTFlagExtensionScripts args.
+	ExtensionScripts []*types.Script
+	// ExtensionHash is the extension scripts hash of XUDTFlagExtensionHash args.
+	ExtensionHash []byte
+}
+
+func ParseXUDTArgs(args []byte) (*XUDTArgs, error) {
+	if len(args) != types.HashLength && len(args) < types.HashLength+flagsLength {
+		return nil, fmt.Errorf("xUDT args are %d bytes", len(args))
+	}
+	a := &XUDTArgs{
+		OwnerLockHash: types.BytesToHash(args[:types.HashLength]),
+	}
+	if len(args) == types.HashLength {
+		return a, nil
+	}
+	a.Flags = binary.LittleEndian.Uint32(args[types.HashLength:])
+	extension := args[types.HashLength+flagsLength:]
+
+	var err error
+	switch a.Flags & xudtExtensionMask {
+	case XUDTFlagExtensionScripts:
+		a.ExtensionScripts, err = molecule.DeserializeScriptVec(extension)
+		if err != nil {
+			return nil, err
+		}
+	case XUDTFlagExtensionHash:
+		if len(extension) != extensionHashSize {
+			return nil, fmt.Errorf("xUDT extension hash is %d bytes, want %d", len(extension), extensionHashSize)
+		}
+		a.ExtensionHash = extension
+	}
+	return a, nil
+}
+
+func (a *XUDTArgs) Serialize() ([]byte, error) {
+	args := append([]byte{}, a.OwnerLockHash.Bytes()...)
+	if a.Flags == 0 && a.ExtensionScripts == nil && a.ExtensionHash == nil {
+		return args, nil
+	}
+	args = append(args, molecule.Uint32(a.Flags)...)
+	switch a.Flags & xudtExtensionMask {
+	case XUDTFlagExtensionScripts:
+		extension, err := molecule.SerializeScriptVec(a.ExtensionScripts)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, extension...)
+	case XUDTFlagExtensionHash:
+		args = append(args, a.ExtensionHash...)
+	}
+	return args, nil
+}
+
+// ExtensionScriptsHash returns the hash XUDTFlagExtensionHash args hold for the extension scripts.
+func ExtensionScriptsHash(scripts []*types.Script) ([]byte, error) {
+	data, err := molecule.SerializeScriptVec(scripts)
+	if err != nil {
+		return nil, err
+	}
+	return blake2b.Blake160(data)
+}
+
+// XUDTTypeScript returns the type script of the token issued by the owner lock with the args flags and extension data.
+func (c *Config) XUDTTypeScript(owner *types.Script, args *XUDTArgs) (*types.Script, error) {
+	hash, err := molecule.ScriptHash(owner)
+	if err != nil {
+		return nil, err
+	}
+	args.OwnerLockHash = hash
+	serialized, err := args.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return &types.Script{
+		CodeHash: c.CodeHash,
+		HashType: c.HashType,
+		Args:     serialized,
+	}, nil
+}
+
+// XUDTWitness is the xUDT data of a witness input type or output type.
+type XUDTWitness struct {
+	OwnerScript    *types.Script
+	OwnerSignature []byte
+	// ExtensionScripts are the extension scripts whose hash the args of XUDTFlagExtensionHash tokens hold.
+	ExtensionScripts []*types.Script
+	// ExtensionData is the witness of each extension script.
+	ExtensionData [][]byte
+}
+
+func (w *XUDTWitness) Serialize() ([]byte, error) {
+	ownerScript, err := molecule.SerializeScriptOpt(w.OwnerScript)
+	if err != nil {
+		return nil, err
+	}
+	ownerSignature := molecule.Option(nil)
+	if w.OwnerSignature != nil {
+		ownerSignature = molecule.Bytes(w.OwnerSignature)
+	}
+	extensionScripts := molecule.Option(nil)
+	if w.ExtensionScripts != nil {
+		extensionScripts, err = molecule.SerializeScriptVec(w.ExtensionScripts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return molecule.Table(ownerScript, ownerSignature, extensionScripts, molecule.BytesVec(w.ExtensionData)), nil
+}
+
+func ParseXUDTWitness(data []byte) (*XUDTWitness, error) {
+	fields, err := molecule.ParseTable(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("xUDT witness has 4 fields but %d are given", len(fields))
+	}
+	w := &XUDTWitness{}
+	if len(fields[0]) > 0 {
+		w.OwnerScript, err = molecule.DeserializeScript(fields[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(fields[1]) > 0 {
+		w.OwnerSignature, err = molecule.ParseBytes(fields[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(fields[2]) > 0 {
+		w.ExtensionScripts, err = molecule.DeserializeScriptVec(fields[2])
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.ExtensionData, err = molecule.ParseBytesVec(fields[3])
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// FindXUDTWitness returns a new xUDT witness holding the extension scripts and extension data of the cell, read from
+// the transaction which created it: the output type of the cell witness, or the input type of a witness spending the
+// same token. Extensions whose data depends on the spending transaction need it replaced before signing. The owner
+// script and owner signature of that witness belong to that transaction and are not carried over.
+func FindXUDTWitness(ctx context.Context, client rpc.Client, cell *indexer.LiveCell, extensionHash []byte) (*XUDTWitness, error) {
+	tx, err := client.GetTransaction(ctx, cell.OutPoint.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	var candidates [][]byte
+	witnesses := tx.Transaction.Witnesses
+	if int(cell.OutPoint.Index) < len(witnesses) {
+		witnessArgs, err := molecule.DeserializeWitnessArgs(witnesses[cell.OutPoint.Index])
+		if err == nil {
+			candidates = append(candidates, witnessArgs.OutputType)
+		}
+	}
+	for _, witness := range witnesses {
+		witnessArgs, err := molecule.DeserializeWitnessArgs(witness)
+		if err == nil {
+			candidates = append(candidates, witnessArgs.InputType)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if len(candidate) == 0 {
+			continue
+		}
+		w, err := ParseXUDTWitness(candidate)
+		if err != nil || w.ExtensionScripts == nil {
+			continue
+		}
+		hash, err := ExtensionScriptsHash(w.ExtensionScripts)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(hash, extensionHash) {
+			return &XUDTWitness{ExtensionScripts: w.ExtensionScripts, ExtensionData: w.ExtensionData}, nil
+		}
+	}
+	return nil, fmt.Errorf("no xUDT witness of extension hash %x in transaction %s", extensionHash, cell.OutPoint.TxHash.String())
+}
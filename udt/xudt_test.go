@@ -0,0 +1,181 @@
+package udt
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/shaojunda/ckb-rich-sdk-go/indexer"
+	"github.com/shaojunda/ckb-rich-sdk-go/molecule"
+)
+
+var (
+	ownerLockHash = types.HexToHash("0x9ec9ae72e4579980e41554100f1219ff97599f8ab7e79f074b72ed8b9dc1d3b1")
+	extension     = &types.Script{
+		CodeHash: types.HexToHash("0x82d76d1b75fe2fd9a27dfbaa65a039221a380d76c926f378d3f81cf3e7e13f2e"),
+		HashType: types.HashTypeType,
+		Args:     []byte{1, 2, 3},
+	}
+)
+
+func TestXUDTArgs(t *testing.T) {
+	hash, err := ExtensionScriptsHash([]*types.Script{extension})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		args   *XUDTArgs
+		length int
+	}{
+		{"owner only", &XUDTArgs{OwnerLockHash: ownerLockHash}, 32},
+		{"owner mode flags", &XUDTArgs{OwnerLockHash: ownerLockHash, Flags: XUDTFlagOwnerModeInputType}, 36},
+		{"extension scripts", &XUDTArgs{OwnerLockHash: ownerLockHash, Flags: XUDTFlagExtensionScripts, ExtensionScripts: []*types.Script{extension}}, 36 + 4 + 4 + 56},
+		{"extension hash", &XUDTArgs{OwnerLockHash: ownerLockHash, Flags: XUDTFlagExtensionHash | XUDTFlagOwnerModeUpdate, ExtensionHash: hash}, 56},
+	}
+	for _, tt := range tests {
+		data, err := tt.args.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != tt.length {
+			t.Errorf("%s: args are %d bytes, want %d", tt.name, len(data), tt.length)
+		}
+		parsed, err := ParseXUDTArgs(data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if parsed.OwnerLockHash != ownerLockHash || parsed.Flags != tt.args.Flags || len(parsed.ExtensionScripts) != len(tt.args.ExtensionScripts) ||
+			!bytes.Equal(parsed.ExtensionHash, tt.args.ExtensionHash) {
+			t.Errorf("%s: parsed args are %+v", tt.name, parsed)
+		}
+	}
+
+	if _, err := ParseXUDTArgs(make([]byte, 33)); err == nil {
+		t.Error("parsed args of 33 bytes")
+	}
+	short, err := (&XUDTArgs{OwnerLockHash: ownerLockHash, Flags: XUDTFlagExtensionHash, ExtensionHash: hash[:19]}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseXUDTArgs(short); err == nil {
+		t.Error("parsed an extension hash of 19 bytes")
+	}
+}
+
+func TestXUDTWitness(t *testing.T) {
+	owner := parse(t, ownerAddress)
+	witnesses := []*XUDTWitness{
+		{ExtensionData: [][]byte{}},
+		{OwnerScript: owner, OwnerSignature: make([]byte, 65), ExtensionScripts: []*types.Script{extension}, ExtensionData: [][]byte{{1}, {}}},
+	}
+	for _, w := range witnesses {
+		data, err := w.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseXUDTWitness(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := parsed.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, again) {
+			t.Errorf("witness %x parses and serializes to %x", data, again)
+		}
+	}
+	if _, err := ParseXUDTWitness(molecule.Table([]byte{}, []byte{})); err == nil {
+		t.Error("parsed a witness of 2 fields")
+	}
+}
+
+// witnessTransaction returns a transaction whose first witness input type holds the xUDT witness of the scripts
+// with an owner signature and extension data, and whose second witness output type holds the witness of other.
+func witnessTransaction(t *testing.T, scripts []*types.Script, other []*types.Script) *types.Transaction {
+	input, err := (&XUDTWitness{OwnerSignature: make([]byte, 65), ExtensionScripts: scripts, ExtensionData: [][]byte{{7}}}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := (&XUDTWitness{ExtensionScripts: other, ExtensionData: [][]byte{}}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.Transaction{Witnesses: [][]byte{
+		molecule.SerializeWitnessArgs(&types.WitnessArgs{Lock: make([]byte, 65), InputType: input}),
+		molecule.SerializeWitnessArgs(&types.WitnessArgs{OutputType: output}),
+	}}
+}
+
+func TestFindXUDTWitness(t *testing.T) {
+	scripts := []*types.Script{extension}
+	other := []*types.Script{{CodeHash: extension.CodeHash, HashType: extension.HashType, Args: []byte{4}}}
+	hash, err := ExtensionScriptsHash(scripts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherHash, err := ExtensionScriptsHash(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &chainClient{transactions: map[types.Hash]*types.Transaction{types.HexToHash(tokenTx): witnessTransaction(t, scripts, other)}}
+	cell := &indexer.LiveCell{OutPoint: &types.OutPoint{TxHash: types.HexToHash(tokenTx), Index: 1}}
+
+	// found in the input type, the extension scripts and data are carried over without the owner signature.
+	w, err := FindXUDTWitness(context.Background(), client, cell, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.ExtensionScripts) != 1 || !sameScript(extension, w.ExtensionScripts[0]) || w.OwnerSignature != nil ||
+		len(w.ExtensionData) != 1 || !bytes.Equal(w.ExtensionData[0], []byte{7}) {
+		t.Errorf("found witness %+v", w)
+	}
+	w, err = FindXUDTWitness(context.Background(), client, cell, otherHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.ExtensionScripts) != 1 || !sameScript(other[0], w.ExtensionScripts[0]) {
+		t.Errorf("found witness %+v in the cell output type", w)
+	}
+	if _, err := FindXUDTWitness(context.Background(), client, cell, make([]byte, 20)); err == nil {
+		t.Error("found a witness of an unknown extension hash")
+	}
+}
+
+func TestTransferXUDT(t *testing.T) {
+	owner := parse(t, ownerAddress)
+	scripts := []*types.Script{extension}
+	hash, err := ExtensionScriptsHash(scripts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := MainnetXUDT.XUDTTypeScript(owner, &XUDTArgs{Flags: XUDTFlagExtensionHash, ExtensionHash: hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, client := testChain(t)
+	client.cells[0] = tokenCell(t, 0, owner, token, 10)
+	client.transactions = map[types.Hash]*types.Transaction{types.HexToHash(tokenTx): witnessTransaction(t, scripts, nil)}
+
+	tx, _, err := NewBuilder(client, MainnetXUDT).Transfer(context.Background(), ownerAddress, receiverAddress, token, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness, err := molecule.DeserializeWitnessArgs(tx.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := (&XUDTWitness{ExtensionScripts: scripts, ExtensionData: [][]byte{{7}}}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(witness.InputType, want) || len(witness.Lock) != 65 {
+		t.Errorf("token input witness is %x", tx.Witnesses[0])
+	}
+	if len(tx.Outputs) != 2 || !sameScript(token, tx.Outputs[0].Type) {
+		t.Errorf("transfer has %d outputs", len(tx.Outputs))
+	}
+}